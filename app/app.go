@@ -76,7 +76,22 @@ func InitApp(configString string) *cli.App {
 			Name:    "debug",
 			Aliases: []string{"db"},
 			Usage:   "interactive debugger (like gdb/dlv)",
-			Action:  command.Debug,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "x",
+					Usage: "execute debugger commands from `FILE` and exit (batch mode)",
+				},
+				&cli.StringFlag{
+					Name:  "output",
+					Value: "text",
+					Usage: "output `FORMAT`: text, or json for one JSON object per command",
+				},
+				&cli.BoolFlag{
+					Name:  "tui",
+					Usage: "full-screen terminal UI with source, stack and locals panes",
+				},
+			},
+			Action: command.Debug,
 		},
 	}
 
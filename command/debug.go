@@ -11,7 +11,32 @@ func Debug(c *cli.Context) error {
 		return cli.Exit(err.Error(), 1)
 	}
 
+	format, err := debugger.ParseOutputFormat(c.String("output"))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
 	repl := debugger.NewREPL(dbg)
+	repl.Format = format
+
+	if c.Bool("tui") {
+		if format == debugger.OutputJSON {
+			return cli.Exit("--tui cannot be combined with --output json", 1)
+		}
+		if err := repl.StartTUI(); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		return nil
+	}
+
+	// 批处理模式：执行脚本后退出，任何命令出错返回非零
+	if script := c.String("x"); script != "" {
+		if err := repl.RunScript(script); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		return nil
+	}
+
 	repl.Start()
 
 	return nil
@@ -0,0 +1,41 @@
+package debugger
+
+// arch 描述与指令集相关的细节（断点指令、PC 语义、寄存器布局），
+// 使 Debugger 的进程控制代码不需要到处判断 GOARCH。
+type arch interface {
+	// Name 架构名，与 GOARCH 一致
+	Name() string
+	// BreakpointInstr 软件断点指令的机器码
+	BreakpointInstr() []byte
+	// BreakpointPCOffset 命中断点后内核报告的 PC 相对断点地址的偏移
+	BreakpointPCOffset() uint64
+	// GetRegisters 读取线程的通用寄存器
+	GetRegisters(pid int) (registers, error)
+	// SetRegisters 写回线程的通用寄存器
+	SetRegisters(pid int, regs registers) error
+	// DwarfSPReg 栈指针的 DWARF 寄存器编号
+	DwarfSPReg() uint64
+	// Disassemble 反汇编 code 开头的一条指令，返回 Go 汇编语法的文本和指令长度，
+	// symbol 把地址解析为符号名和符号起始地址
+	Disassemble(code []byte, pc uint64, symbol func(uint64) (string, uint64)) (string, int, error)
+}
+
+// registers 一个线程的通用寄存器快照
+type registers interface {
+	PC() uint64
+	SP() uint64
+	// FP 帧指针（amd64 为 rbp，arm64 为 x29）
+	FP() uint64
+	SetPC(pc uint64)
+	// Named 按架构习惯的顺序返回寄存器名和值
+	Named() []namedRegister
+	// Dwarf 按 DWARF 寄存器编号返回寄存器值，用于栈回溯
+	Dwarf() map[uint64]uint64
+	// SetDwarf 按 DWARF 寄存器编号修改寄存器，编号未知时返回 false
+	SetDwarf(n, v uint64) bool
+}
+
+type namedRegister struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
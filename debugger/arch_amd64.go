@@ -0,0 +1,91 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package debugger
+
+import (
+	"syscall"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+type amd64Arch struct{}
+
+func nativeArch() arch { return amd64Arch{} }
+
+func (amd64Arch) Name() string { return "amd64" }
+
+// int3
+func (amd64Arch) BreakpointInstr() []byte { return []byte{0xcc} }
+
+// int3 执行后 rip 指向下一条指令
+func (amd64Arch) BreakpointPCOffset() uint64 { return 1 }
+
+func (amd64Arch) GetRegisters(pid int) (registers, error) {
+	regs := &amd64Regs{}
+	if err := syscall.PtraceGetRegs(pid, &regs.raw); err != nil {
+		return nil, err
+	}
+	return regs, nil
+}
+
+func (amd64Arch) SetRegisters(pid int, regs registers) error {
+	return syscall.PtraceSetRegs(pid, &regs.(*amd64Regs).raw)
+}
+
+func (amd64Arch) DwarfSPReg() uint64 { return 7 }
+
+func (amd64Arch) Disassemble(code []byte, pc uint64, symbol func(uint64) (string, uint64)) (string, int, error) {
+	inst, err := x86asm.Decode(code, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return x86asm.GoSyntax(inst, pc, symbol), inst.Len, nil
+}
+
+type amd64Regs struct {
+	raw syscall.PtraceRegs
+}
+
+func (r *amd64Regs) PC() uint64      { return r.raw.Rip }
+func (r *amd64Regs) SP() uint64      { return r.raw.Rsp }
+func (r *amd64Regs) FP() uint64      { return r.raw.Rbp }
+func (r *amd64Regs) SetPC(pc uint64) { r.raw.Rip = pc }
+
+func (r *amd64Regs) Named() []namedRegister {
+	return []namedRegister{
+		{"rax", r.raw.Rax}, {"rbx", r.raw.Rbx}, {"rcx", r.raw.Rcx}, {"rdx", r.raw.Rdx},
+		{"rsi", r.raw.Rsi}, {"rdi", r.raw.Rdi}, {"rbp", r.raw.Rbp}, {"rsp", r.raw.Rsp},
+		{"r8", r.raw.R8}, {"r9", r.raw.R9}, {"r10", r.raw.R10}, {"r11", r.raw.R11},
+		{"r12", r.raw.R12}, {"r13", r.raw.R13}, {"r14", r.raw.R14}, {"r15", r.raw.R15},
+		{"rip", r.raw.Rip}, {"eflags", r.raw.Eflags},
+		{"cs", r.raw.Cs}, {"ss", r.raw.Ss}, {"ds", r.raw.Ds}, {"es", r.raw.Es},
+		{"fs", r.raw.Fs}, {"gs", r.raw.Gs}, {"fs_base", r.raw.Fs_base}, {"gs_base", r.raw.Gs_base},
+	}
+}
+
+// System V AMD64 ABI 的 DWARF 寄存器编号
+func (r *amd64Regs) Dwarf() map[uint64]uint64 {
+	return map[uint64]uint64{
+		0: r.raw.Rax, 1: r.raw.Rdx, 2: r.raw.Rcx, 3: r.raw.Rbx,
+		4: r.raw.Rsi, 5: r.raw.Rdi, 6: r.raw.Rbp, 7: r.raw.Rsp,
+		8: r.raw.R8, 9: r.raw.R9, 10: r.raw.R10, 11: r.raw.R11,
+		12: r.raw.R12, 13: r.raw.R13, 14: r.raw.R14, 15: r.raw.R15,
+		16: r.raw.Rip,
+	}
+}
+
+func (r *amd64Regs) SetDwarf(n, v uint64) bool {
+	regs := []*uint64{
+		&r.raw.Rax, &r.raw.Rdx, &r.raw.Rcx, &r.raw.Rbx,
+		&r.raw.Rsi, &r.raw.Rdi, &r.raw.Rbp, &r.raw.Rsp,
+		&r.raw.R8, &r.raw.R9, &r.raw.R10, &r.raw.R11,
+		&r.raw.R12, &r.raw.R13, &r.raw.R14, &r.raw.R15,
+		&r.raw.Rip,
+	}
+	if n >= uint64(len(regs)) {
+		return false
+	}
+	*regs[n] = v
+	return true
+}
@@ -0,0 +1,111 @@
+//go:build linux && arm64
+// +build linux,arm64
+
+package debugger
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/arch/arm64/arm64asm"
+)
+
+// NT_PRSTATUS 通用寄存器集合（elf.h）
+const ntPRStatus = 1
+
+type arm64Arch struct{}
+
+func nativeArch() arch { return arm64Arch{} }
+
+func (arm64Arch) Name() string { return "arm64" }
+
+// BRK #0 (0xd4200000)，小端序
+func (arm64Arch) BreakpointInstr() []byte { return []byte{0x00, 0x00, 0x20, 0xd4} }
+
+// BRK 触发异常时 PC 仍指向断点指令本身
+func (arm64Arch) BreakpointPCOffset() uint64 { return 0 }
+
+// arm64 内核不支持 PTRACE_GETREGS，需要使用 PTRACE_GETREGSET + NT_PRSTATUS
+func (arm64Arch) GetRegisters(pid int) (registers, error) {
+	regs := &arm64Regs{}
+	if err := ptraceRegset(syscall.PTRACE_GETREGSET, pid, &regs.raw); err != nil {
+		return nil, err
+	}
+	return regs, nil
+}
+
+func (arm64Arch) SetRegisters(pid int, regs registers) error {
+	return ptraceRegset(syscall.PTRACE_SETREGSET, pid, &regs.(*arm64Regs).raw)
+}
+
+func ptraceRegset(request int, pid int, raw *syscall.PtraceRegs) error {
+	iov := syscall.Iovec{Base: (*byte)(unsafe.Pointer(raw))}
+	iov.SetLen(int(unsafe.Sizeof(*raw)))
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, uintptr(request), uintptr(pid),
+		ntPRStatus, uintptr(unsafe.Pointer(&iov)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	if iov.Len != uint64(unsafe.Sizeof(*raw)) {
+		return fmt.Errorf("unexpected NT_PRSTATUS size %d", iov.Len)
+	}
+	return nil
+}
+
+func (arm64Arch) DwarfSPReg() uint64 { return 31 }
+
+// arm64 指令定长 4 字节
+func (arm64Arch) Disassemble(code []byte, pc uint64, symbol func(uint64) (string, uint64)) (string, int, error) {
+	inst, err := arm64asm.Decode(code)
+	if err != nil {
+		return "", 0, err
+	}
+	return arm64asm.GoSyntax(inst, pc, symbol, nil), 4, nil
+}
+
+type arm64Regs struct {
+	raw syscall.PtraceRegs
+}
+
+func (r *arm64Regs) PC() uint64      { return r.raw.Pc }
+func (r *arm64Regs) SP() uint64      { return r.raw.Sp }
+func (r *arm64Regs) FP() uint64      { return r.raw.Regs[29] }
+func (r *arm64Regs) SetPC(pc uint64) { r.raw.Pc = pc }
+
+func (r *arm64Regs) Named() []namedRegister {
+	named := make([]namedRegister, 0, len(r.raw.Regs)+3)
+	for i, v := range r.raw.Regs {
+		named = append(named, namedRegister{fmt.Sprintf("x%d", i), v})
+	}
+	return append(named,
+		namedRegister{"sp", r.raw.Sp},
+		namedRegister{"pc", r.raw.Pc},
+		namedRegister{"pstate", r.raw.Pstate},
+	)
+}
+
+// AArch64 DWARF 寄存器编号：x0-x30 为 0-30，sp 为 31，pc 为 32
+func (r *arm64Regs) Dwarf() map[uint64]uint64 {
+	regs := make(map[uint64]uint64, len(r.raw.Regs)+2)
+	for i, v := range r.raw.Regs {
+		regs[uint64(i)] = v
+	}
+	regs[31] = r.raw.Sp
+	regs[32] = r.raw.Pc
+	return regs
+}
+
+func (r *arm64Regs) SetDwarf(n, v uint64) bool {
+	switch {
+	case n < uint64(len(r.raw.Regs)):
+		r.raw.Regs[n] = v
+	case n == 31:
+		r.raw.Sp = v
+	case n == 32:
+		r.raw.Pc = v
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,840 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxCallString 解码返回值中的字符串时最多读取的字节数
+const maxCallString = 1024
+
+// CallValue 函数调用的一个返回值
+type CallValue struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// callOutcome 注入调用的原始结果：返回值所在的整数寄存器和栈上的返回值，或 panic 的接口值
+type callOutcome struct {
+	results   []uint64
+	stack     []byte // 从 SP 开始到栈上返回值结束的参数帧内容
+	panicked  bool
+	panicType uint64
+	panicData uint64
+}
+
+// errCallStopped 程序在注入的调用中停下，调用在 continue 时完成
+var errCallStopped = errors.New("the program stopped inside the called function; 'continue' finishes the call")
+
+// pendingCall 一次注入的调用：调用前的寄存器和已经收到的结果。
+// 程序在调用中停下时保存在 Debugger.call 中，continue 时继续完成调用协议
+type pendingCall struct {
+	name    string
+	tid     int
+	entry   uint64
+	frame   *callFrame
+	results []callParam
+	hit     *Breakpoint // 调用前命中的断点，调用结束后恢复
+
+	saved  registers // 调用前的寄存器
+	fpregs []byte
+	out    callOutcome
+	err    error // 运行时拒绝执行调用的原因
+}
+
+// callParam 函数的一个参数或返回值
+type callParam struct {
+	name string
+	typ  dwarf.Type
+}
+
+// CallArg call 的一个实参。String 非 nil 时是字符串字面量，Data 非 nil 时是类型为 Type 的变量的内容，
+// 否则是整数、布尔值或地址 Value
+type CallArg struct {
+	Value  uint64
+	String *string
+	Data   []byte
+	Type   dwarf.Type
+}
+
+// Call 在当前线程上调用被调试程序中的函数。参数和返回值按 ABIInternal 的规则放在整数寄存器中，
+// 放不下的放在栈上；不支持浮点数。调用结束后寄存器恢复原状，程序仍停在原来的位置。
+func (d *Debugger) Call(name string, args []CallArg) ([]CallValue, error) {
+	if !d.IsRunning {
+		return nil, errNotRunning
+	}
+	if !d.Capabilities().Call {
+		return nil, unsupported("call")
+	}
+	if d.record != nil {
+		// 注入的调用不经过记录，之后反向执行会得到不一致的状态
+		return nil, fmt.Errorf("cannot call functions in record mode (use 'record stop' first)")
+	}
+	if err := d.callPending(); err != nil {
+		return nil, err
+	}
+	if d.index == nil {
+		return nil, fmt.Errorf("no debug information")
+	}
+	fn := d.index.lookupFunc(name)
+	if fn == nil {
+		return nil, fmt.Errorf("function '%s' not found", name)
+	}
+	params, results, err := d.funcSignature(fn)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(params) {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name, len(params), len(args))
+	}
+	frame, err := newCallFrame(params, results, args)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &pendingCall{name: name, tid: d.tid, entry: fn.LowPC, frame: frame, results: results, hit: d.hit}
+	d.stopSeq++
+	out, err := d.callFunction(c)
+	if err != nil {
+		return nil, err
+	}
+	return d.callValues(c, out)
+}
+
+// finishCall continue 时完成停在被调用函数中的调用，调用结束后程序回到调用前的位置，
+// 返回值随 EventCallReturned 报告。调用中再次停下时调用继续保留。
+func (d *Debugger) finishCall() error {
+	c := d.call
+	out, err := d.driveCall(c)
+	if err == errCallStopped || !d.IsRunning {
+		// 停下或退出的事件已经发出
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	e := Event{Kind: EventCallReturned, Thread: c.tid, Call: c.name}
+	if values, err := d.callValues(c, out); err != nil {
+		e.Message = err.Error()
+	} else {
+		e.Return = formatCallValues(values)
+	}
+	d.emit(e)
+	return nil
+}
+
+// callPending 程序停在注入的调用中时，除了 continue 不能让它运行，否则调用协议无法完成
+func (d *Debugger) callPending() error {
+	if d.call == nil || d.calling {
+		return nil
+	}
+	return fmt.Errorf("the program is stopped inside the call to %s; use 'continue' to finish it first", d.call.name)
+}
+
+// callValues 解码调用的返回值，被调用的函数 panic 时返回错误
+func (d *Debugger) callValues(c *pendingCall, out callOutcome) ([]CallValue, error) {
+	if out.panicked {
+		return nil, fmt.Errorf("%s panicked: %s", c.name, d.formatInterface(out.panicType, out.panicData))
+	}
+
+	var values []CallValue
+	regs := out.results
+	for i, p := range c.results {
+		var value string
+		if slot := c.frame.results[i]; slot.reg >= 0 {
+			value, regs = d.decodeValue(p.typ, regs)
+		} else {
+			data := out.stack[slot.off : slot.off+uint64(p.typ.Size())]
+			value = valueReader{d: d, data: data}.format(p.typ, registerValueBase, 0)
+		}
+		v := CallValue{Type: p.typ.String(), Value: value}
+		if !strings.HasPrefix(p.name, "~") {
+			v.Name = p.name
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// abiSlot 值的位置：从第 reg 个整数寄存器开始，reg 为 -1 时在参数帧的 off 处
+type abiSlot struct {
+	reg int
+	off uint64
+}
+
+// abiLayout 按 ABIInternal 的规则依次为值分配寄存器，放不进剩余寄存器的值整个放到栈上
+type abiLayout struct {
+	regs  int    // 已经使用的整数寄存器个数
+	stack uint64 // 栈上部分的大小
+}
+
+// place 为类型为 t 的值分配位置
+func (l *abiLayout) place(t dwarf.Type) (abiSlot, error) {
+	n, ok, err := regWords(t)
+	if err != nil {
+		return abiSlot{}, err
+	}
+	if ok && l.regs+n <= callArgRegs {
+		l.regs += n
+		return abiSlot{reg: l.regs - n}, nil
+	}
+	off := alignUp(l.stack, typeAlign(t))
+	l.stack = off + uint64(t.Size())
+	return abiSlot{reg: -1, off: off}, nil
+}
+
+// callString 字符串字面量：内容放在参数帧的 off 处，指针放在 slot 的第一个字
+type callString struct {
+	data []byte
+	off  uint64
+	slot abiSlot
+}
+
+// callFrame 按 ABIInternal 分配好的一次调用。参数帧从 SP 开始依次是栈上的参数、栈上的返回值、
+// 寄存器参数的溢出区，最后是字符串字面量的内容，只在调用期间有效。
+type callFrame struct {
+	size    uint64
+	regs    []uint64 // 寄存器中的参数
+	stack   []byte   // 栈上的参数
+	strings []callString
+	results []abiSlot
+	resEnd  uint64 // 栈上返回值结束的偏移
+}
+
+// newCallFrame 按参数类型整理实参，为参数和返回值分配寄存器和栈上的位置
+func newCallFrame(params, results []callParam, args []CallArg) (*callFrame, error) {
+	f := &callFrame{}
+	var l abiLayout
+	var spill uint64
+	for i, p := range params {
+		img, err := argImage(p.typ, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", p.name, err)
+		}
+		slot, err := l.place(p.typ)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", p.name, err)
+		}
+		if slot.reg >= 0 {
+			f.regs = append(f.regs, abiWords(p.typ, img)...)
+			spill = alignUp(spill, typeAlign(p.typ)) + uint64(p.typ.Size())
+		} else {
+			if end := slot.off + uint64(len(img)); end > uint64(len(f.stack)) {
+				f.stack = append(f.stack, make([]byte, end-uint64(len(f.stack)))...)
+			}
+			copy(f.stack[slot.off:], img)
+		}
+		if s := args[i].String; s != nil {
+			f.strings = append(f.strings, callString{data: []byte(*s), slot: slot})
+		}
+	}
+
+	// 返回值重新从第一个寄存器开始分配，栈上的返回值跟在栈上的参数之后
+	l = abiLayout{stack: alignUp(l.stack, 8)}
+	for _, p := range results {
+		slot, err := l.place(p.typ)
+		if err != nil {
+			return nil, fmt.Errorf("result %s: %v", p.typ, err)
+		}
+		f.results = append(f.results, slot)
+	}
+	f.resEnd = alignUp(l.stack, 8)
+	end := f.resEnd + alignUp(spill, 8)
+	for i := range f.strings {
+		f.strings[i].off = end
+		end += uint64(len(f.strings[i].data))
+	}
+	f.size = alignUp(end, 8)
+	return f, nil
+}
+
+// build 按调用时的 SP 填入字符串字面量的地址，返回参数寄存器的值和要写到 SP 处的参数帧
+func (f *callFrame) build(sp uint64) ([]uint64, []byte) {
+	regs := append([]uint64(nil), f.regs...)
+	frame := make([]byte, f.size)
+	copy(frame, f.stack)
+	for _, s := range f.strings {
+		copy(frame[s.off:], s.data)
+		if s.slot.reg >= 0 {
+			regs[s.slot.reg] = sp + s.off
+		} else {
+			binary.LittleEndian.PutUint64(frame[s.slot.off:], sp+s.off)
+		}
+	}
+	return regs, frame
+}
+
+// argImage 按参数类型得到实参在内存中的表示
+func argImage(t dwarf.Type, arg CallArg) ([]byte, error) {
+	size := t.Size()
+	switch {
+	case arg.String != nil:
+		if s, ok := underlying(t).(*dwarf.StructType); !ok || s.StructName != "string" {
+			return nil, fmt.Errorf("cannot use a string literal as %s", t)
+		}
+		img := make([]byte, 16)
+		binary.LittleEndian.PutUint64(img[8:], uint64(len(*arg.String)))
+		return img, nil
+	case arg.Data != nil && arg.Type.String() == t.String() && int64(len(arg.Data)) == size:
+		return arg.Data, nil
+	case arg.Data != nil:
+		// 不同类型的整数变量按值转换，其他类型必须一致
+		if !isScalar(t) || !isScalar(arg.Type) || len(arg.Data) > 8 {
+			return nil, fmt.Errorf("cannot use %s value as %s", arg.Type, t)
+		}
+		arg.Value = littleEndian(arg.Data)
+		if _, signed := underlying(arg.Type).(*dwarf.IntType); signed {
+			arg.Value = uint64(signExtend(arg.Data))
+		}
+	}
+	if !isScalar(t) || size > 8 {
+		return nil, fmt.Errorf("cannot use %d as %s", arg.Value, t)
+	}
+	img := make([]byte, 8)
+	binary.LittleEndian.PutUint64(img, arg.Value)
+	return img[:size], nil
+}
+
+// funcSignature 从函数的 DIE 读取参数和返回值（DW_AT_variable_parameter 标记返回值）
+func (d *Debugger) funcSignature(fn *funcEntry) (params, results []callParam, err error) {
+	reader := d.DwarfData.Reader()
+	reader.Seek(fn.Offset)
+	entry, err := reader.Next()
+	if err != nil || entry == nil || !entry.Children {
+		return nil, nil, err
+	}
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if entry == nil || entry.Tag == 0 {
+			break
+		}
+		if entry.Tag != dwarf.TagFormalParameter {
+			if entry.Children {
+				reader.SkipChildren()
+			}
+			continue
+		}
+		off, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+		if !ok {
+			continue
+		}
+		typ, err := d.DwarfData.Type(off)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read type: %v", err)
+		}
+		p := callParam{typ: typ}
+		p.name, _ = entry.Val(dwarf.AttrName).(string)
+		if isResult, _ := entry.Val(dwarf.AttrVarParam).(bool); isResult {
+			results = append(results, p)
+		} else {
+			params = append(params, p)
+		}
+	}
+	return params, results, nil
+}
+
+// underlying 去掉 typedef 得到实际类型
+func underlying(t dwarf.Type) dwarf.Type {
+	for {
+		td, ok := t.(*dwarf.TypedefType)
+		if !ok {
+			return t
+		}
+		t = td.Type
+	}
+}
+
+// isScalar 判断类型能否放进一个整数寄存器
+func isScalar(t dwarf.Type) bool {
+	switch underlying(t).(type) {
+	case *dwarf.IntType, *dwarf.UintType, *dwarf.BoolType, *dwarf.CharType,
+		*dwarf.UcharType, *dwarf.PtrType, *dwarf.AddrType:
+		return true
+	}
+	return false
+}
+
+// regWords 按 ABIInternal 的规则计算类型需要的整数寄存器个数，ok 为 false 时只能放在栈上
+func regWords(t dwarf.Type) (n int, ok bool, err error) {
+	if isScalar(t) {
+		return 1, true, nil
+	}
+	switch t := underlying(t).(type) {
+	case *dwarf.StructType:
+		ok = true
+		for _, f := range t.Field {
+			w, fok, err := regWords(f.Type)
+			if err != nil {
+				return 0, false, err
+			}
+			n, ok = n+w, ok && fok
+		}
+		return n, ok, nil
+	case *dwarf.ArrayType:
+		switch t.Count {
+		case 0:
+			return 0, true, nil
+		case 1:
+			return regWords(t.Type)
+		}
+		if _, _, err := regWords(t.Type); err != nil {
+			return 0, false, err
+		}
+		return 0, false, nil
+	case *dwarf.FloatType, *dwarf.ComplexType:
+		return 0, false, fmt.Errorf("floating-point values are not supported")
+	}
+	return 0, false, fmt.Errorf("unsupported type")
+}
+
+// abiWords 把能放进寄存器的值按字段顺序拆成寄存器的值
+func abiWords(t dwarf.Type, data []byte) []uint64 {
+	switch u := underlying(t).(type) {
+	case *dwarf.StructType:
+		var words []uint64
+		for _, f := range u.Field {
+			words = append(words, abiWords(f.Type, data[f.ByteOffset:])...)
+		}
+		return words
+	case *dwarf.ArrayType:
+		if u.Count == 1 {
+			return abiWords(u.Type, data)
+		}
+		return nil
+	}
+	return []uint64{littleEndian(data[:t.Size()])}
+}
+
+// typeAlign 类型的对齐要求
+func typeAlign(t dwarf.Type) uint64 {
+	switch u := underlying(t).(type) {
+	case *dwarf.StructType:
+		align := uint64(1)
+		for _, f := range u.Field {
+			if a := typeAlign(f.Type); a > align {
+				align = a
+			}
+		}
+		return align
+	case *dwarf.ArrayType:
+		return typeAlign(u.Type)
+	case *dwarf.ComplexType:
+		return uint64(u.Size() / 2)
+	}
+	if size := t.Size(); size > 0 && size < 8 {
+		return uint64(size)
+	}
+	return 8
+}
+
+// alignUp 把 n 向上对齐到 align 的倍数
+func alignUp(n, align uint64) uint64 {
+	return (n + align - 1) &^ (align - 1)
+}
+
+// decodeValue 从寄存器值中解码一个值，返回剩余的寄存器
+func (d *Debugger) decodeValue(t dwarf.Type, regs []uint64) (string, []uint64) {
+	if len(regs) == 0 {
+		return "<unavailable>", nil
+	}
+	switch u := underlying(t).(type) {
+	case *dwarf.IntType, *dwarf.CharType:
+		bits := uint(64 - u.Size()*8)
+		return strconv.FormatInt(int64(regs[0]<<bits)>>bits, 10), regs[1:]
+	case *dwarf.UintType, *dwarf.UcharType:
+		bits := uint(64 - u.Size()*8)
+		return strconv.FormatUint(regs[0]<<bits>>bits, 10), regs[1:]
+	case *dwarf.BoolType:
+		return strconv.FormatBool(regs[0]&0xff != 0), regs[1:]
+	case *dwarf.PtrType, *dwarf.AddrType:
+		if regs[0] == 0 {
+			return "nil", regs[1:]
+		}
+		return fmt.Sprintf("0x%x", regs[0]), regs[1:]
+	case *dwarf.StructType:
+		return d.decodeStruct(u, regs)
+	case *dwarf.ArrayType:
+		if u.Count == 1 {
+			v, rest := d.decodeValue(u.Type, regs)
+			return "[" + v + "]", rest
+		}
+		return "[]", regs
+	}
+	return "<unavailable>", regs
+}
+
+// decodeStruct 解码字符串、切片、接口和普通结构体
+func (d *Debugger) decodeStruct(t *dwarf.StructType, regs []uint64) (string, []uint64) {
+	switch {
+	case t.StructName == "string" && len(regs) >= 2:
+		return d.readGoString(regs[0], regs[1]), regs[2:]
+	case strings.HasPrefix(t.StructName, "[]") && len(regs) >= 3:
+		return fmt.Sprintf("%s{ptr: 0x%x, len: %d, cap: %d}", t.StructName, regs[0], regs[1], regs[2]), regs[3:]
+	case t.StructName == "runtime.eface" && len(regs) >= 2:
+		return d.formatInterface(regs[0], regs[1]), regs[2:]
+	case t.StructName == "runtime.iface" && len(regs) >= 2:
+		// itab 的第二个字段是动态类型
+		var typ uint64
+		if regs[0] != 0 {
+			if data, err := d.ReadMemory(regs[0]+8, 8); err == nil {
+				typ = binary.LittleEndian.Uint64(data)
+			}
+		}
+		return d.formatInterface(typ, regs[1]), regs[2:]
+	}
+
+	fields := make([]string, 0, len(t.Field))
+	for _, f := range t.Field {
+		var v string
+		v, regs = d.decodeValue(f.Type, regs)
+		fields = append(fields, f.Name+": "+v)
+	}
+	return t.StructName + "{" + strings.Join(fields, ", ") + "}", regs
+}
+
+// readGoString 读取 Go 字符串的内容，过长时截断
+func (d *Debugger) readGoString(ptr, n uint64) string {
+	if n == 0 {
+		return `""`
+	}
+	size := n
+	if size > maxCallString {
+		size = maxCallString
+	}
+	data, err := d.ReadMemory(ptr, int(size))
+	if err != nil {
+		return fmt.Sprintf("<string at 0x%x, len %d>", ptr, n)
+	}
+	s := strconv.Quote(string(data))
+	if size < n {
+		s += fmt.Sprintf("...(%d more bytes)", n-size)
+	}
+	return s
+}
+
+// formatInterface 根据运行时类型描述符格式化接口值，只解码常见的动态类型
+func (d *Debugger) formatInterface(typ, data uint64) string {
+	if typ == 0 {
+		return "nil"
+	}
+	name, kind, size, err := d.runtimeType(typ)
+	if err != nil {
+		return fmt.Sprintf("(0x%x) 0x%x", typ, data)
+	}
+	readWords := func(n int) []uint64 {
+		words, _ := d.readWords(data, n)
+		return words
+	}
+
+	switch {
+	case kind == abiString:
+		if w := readWords(2); w != nil && name == "string" {
+			return d.readGoString(w[0], w[1])
+		} else if w != nil {
+			return fmt.Sprintf("(%s) %s", name, d.readGoString(w[0], w[1]))
+		}
+	case name == "*errors.errorString":
+		// type errorString struct { s string }
+		if w := readWords(2); w != nil {
+			return fmt.Sprintf("(%s) %s", name, d.readGoString(w[0], w[1]))
+		}
+	case kind >= abiInt && kind <= abiInt64 && size <= 8:
+		if w := readWords(1); w != nil {
+			bits := uint(64 - size*8)
+			return fmt.Sprintf("(%s) %d", name, int64(w[0]<<bits)>>bits)
+		}
+	case kind >= abiUint && kind <= abiUintptr && size <= 8:
+		if w := readWords(1); w != nil {
+			bits := uint(64 - size*8)
+			return fmt.Sprintf("(%s) %d", name, w[0]<<bits>>bits)
+		}
+	}
+	return fmt.Sprintf("(%s) 0x%x", name, data)
+}
+
+// internal/abi.Kind 中用到的值
+const (
+	abiInt      = 2
+	abiInt64    = 6
+	abiUint     = 7
+	abiUintptr  = 12
+	abiString   = 24
+	abiKindMask = 0x1f
+
+	abiTFlagExtraStar = 1 << 1
+)
+
+// runtimeType 读取 internal/abi.Type：类型名、Kind 和大小。
+// 类型名是相对 runtime.types 的 NameOff，编码为 标志字节 + uvarint 长度 + 内容。
+func (d *Debugger) runtimeType(typ uint64) (string, int, int64, error) {
+	types, ok := d.Symbols["runtime.types"]
+	if !ok {
+		return "", 0, 0, fmt.Errorf("runtime.types not found")
+	}
+	// Size_ 0, TFlag 20, Kind_ 23, Str 40
+	hdr, err := d.ReadMemory(typ, 48)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	size := int64(binary.LittleEndian.Uint64(hdr[0:]))
+	tflag, kind := hdr[20], int(hdr[23]&abiKindMask)
+	nameOff := int32(binary.LittleEndian.Uint32(hdr[40:]))
+
+	buf, err := d.ReadMemory(types+uint64(int64(nameOff)), 11)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	n, w := binary.Uvarint(buf[1:])
+	if w <= 0 {
+		return "", 0, 0, fmt.Errorf("invalid type name at 0x%x", typ)
+	}
+	name, err := d.ReadMemory(types+uint64(int64(nameOff))+1+uint64(w), int(n))
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if tflag&abiTFlagExtraStar != 0 && len(name) > 0 {
+		name = name[1:]
+	}
+	return string(name), kind, size, nil
+}
+
+// writeWord 写入一个 8 字节的小端序整数
+func (d *Debugger) writeWord(addr, v uint64) error {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, v)
+	return d.writeMemory(addr, buf)
+}
+
+// readWords 读取 n 个 8 字节的小端序整数
+func (d *Debugger) readWords(addr uint64, n int) ([]uint64, error) {
+	data, err := d.readMemory(addr, n*8)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]uint64, n)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+	return words, nil
+}
+
+// callResult call 命令的输出
+type callResult struct {
+	Function string      `json:"function"`
+	Args     []string    `json:"args"`
+	Values   []CallValue `json:"values"`
+}
+
+func (res callResult) printText(w io.Writer) {
+	if len(res.Values) == 0 {
+		fmt.Fprintf(w, "%s returned\n", res.Function)
+		return
+	}
+	fmt.Fprintln(w, formatCallValues(res.Values))
+}
+
+// formatCallValues 一个返回值时只有值，多个返回值放在括号中，带名字的返回值显示为 name = value
+func formatCallValues(values []CallValue) string {
+	if len(values) == 0 {
+		return ""
+	}
+	var strs []string
+	for _, v := range values {
+		if v.Name != "" {
+			strs = append(strs, v.Name+" = "+v.Value)
+		} else {
+			strs = append(strs, v.Value)
+		}
+	}
+	if len(strs) == 1 {
+		return strs[0]
+	}
+	return "(" + strings.Join(strs, ", ") + ")"
+}
+
+// cmdCall call pkg.Func(arg, ...)，参数可以是字符串、整数、true/false、变量或地址表达式
+func (r *REPL) cmdCall(args []string) error {
+	name, params, err := parseCall(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	values := make([]CallArg, 0, len(params))
+	for _, p := range params {
+		v, err := r.evalCallArg(p)
+		if err != nil {
+			return fmt.Errorf("argument %s: %w", p, err)
+		}
+		values = append(values, v)
+	}
+	r.running.Store(r.Debugger)
+	results, err := r.Debugger.Call(name, values)
+	r.running.Store(nil)
+	if err == errCallStopped {
+		// 被调用的函数中命中断点等，与 continue 停下时相同地报告
+		return r.reportStop(*r.Debugger.LastStop())
+	}
+	if err != nil {
+		return err
+	}
+	r.output(callResult{Function: name, Args: params, Values: results})
+	return nil
+}
+
+// parseCall 拆分 "main.(*T).String(p, 1)" 为函数名和参数，参数列表是末尾的一对括号
+func parseCall(expr string) (string, []string, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasSuffix(expr, ")") {
+		return "", nil, fmt.Errorf("usage: call <func>(<args>)")
+	}
+	// 函数名中不会有引号，从参数列表的开头向后找与末尾匹配的左括号
+	open := strings.IndexByte(expr, '(')
+	for open >= 0 {
+		if end, ok := matchParen(expr, open); ok && end == len(expr)-1 {
+			break
+		}
+		next := strings.IndexByte(expr[open+1:], '(')
+		if next < 0 {
+			open = -1
+			break
+		}
+		open += next + 1
+	}
+	if open <= 0 {
+		return "", nil, fmt.Errorf("usage: call <func>(<args>)")
+	}
+	name := strings.TrimSpace(expr[:open])
+	params, err := splitArgs(expr[open+1 : len(expr)-1])
+	if err != nil {
+		return "", nil, err
+	}
+	return name, params, nil
+}
+
+// matchParen 返回与 open 处左括号匹配的右括号的位置，跳过字符串字面量
+func matchParen(expr string, open int) (int, bool) {
+	depth := 0
+	for i := open; i < len(expr); i++ {
+		switch expr[i] {
+		case '"', '`', '\'':
+			end, ok := skipQuoted(expr, i)
+			if !ok {
+				return 0, false
+			}
+			i = end
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// skipQuoted 返回从 start 开始的字符串或字符字面量最后一个字符的位置
+func skipQuoted(expr string, start int) (int, bool) {
+	quote := expr[start]
+	for i := start + 1; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\\' && quote != '`':
+			i++
+		case expr[i] == quote:
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// splitArgs 按顶层的逗号拆分参数列表，字符串和括号中的逗号不拆分
+func splitArgs(inner string) ([]string, error) {
+	if strings.TrimSpace(inner) == "" {
+		return nil, nil
+	}
+	var params []string
+	depth, start := 0, 0
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '"', '`', '\'':
+			end, ok := skipQuoted(inner, i)
+			if !ok {
+				return nil, fmt.Errorf("unterminated literal: %s", inner[i:])
+			}
+			i = end
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(inner[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	params = append(params, strings.TrimSpace(inner[start:]))
+	for _, p := range params {
+		if p == "" {
+			return nil, fmt.Errorf("empty argument")
+		}
+	}
+	return params, nil
+}
+
+// evalCallArg 计算一个参数：先解析字符串、字符、布尔值和数字字面量，然后查找变量，
+// 其余按地址表达式处理
+func (r *REPL) evalCallArg(arg string) (CallArg, error) {
+	switch {
+	case arg[0] == '"' || arg[0] == '`':
+		s, err := strconv.Unquote(arg)
+		if err != nil {
+			return CallArg{}, fmt.Errorf("invalid string literal: %s", arg)
+		}
+		return CallArg{String: &s}, nil
+	case arg[0] == '\'':
+		c, _, tail, err := strconv.UnquoteChar(strings.TrimSuffix(arg[1:], "'"), '\'')
+		if err != nil || tail != "" || !strings.HasSuffix(arg[1:], "'") {
+			return CallArg{}, fmt.Errorf("invalid character literal: %s", arg)
+		}
+		return CallArg{Value: uint64(c)}, nil
+	case arg == "true":
+		return CallArg{Value: 1}, nil
+	case arg == "false":
+		return CallArg{Value: 0}, nil
+	case arg[0] == '-' || arg[0] >= '0' && arg[0] <= '9':
+		if v, err := strconv.ParseInt(arg, 0, 64); err == nil {
+			return CallArg{Value: uint64(v)}, nil
+		}
+		if v, err := strconv.ParseUint(arg, 0, 64); err == nil {
+			return CallArg{Value: v}, nil
+		}
+		return CallArg{}, fmt.Errorf("invalid number: %s", arg)
+	}
+	if entry, loc, err := r.Debugger.findVariable(arg, r.frame()); err == nil {
+		typ, err := r.Debugger.entryType(entry)
+		if err != nil {
+			return CallArg{}, err
+		}
+		data := loc.data
+		if data == nil {
+			if data, err = r.Debugger.ReadMemory(loc.addr, int(typ.Size())); err != nil {
+				return CallArg{}, err
+			}
+		}
+		if int64(len(data)) < typ.Size() {
+			return CallArg{}, fmt.Errorf("value of %s is only partially available", arg)
+		}
+		return CallArg{Data: data[:typ.Size()], Type: typ}, nil
+	}
+	v, err := r.evalAddress(arg)
+	return CallArg{Value: v}, err
+}
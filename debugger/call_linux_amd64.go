@@ -0,0 +1,207 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package debugger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// callArgRegs ABIInternal 传递整数参数和返回值的寄存器个数
+const callArgRegs = 9
+
+// debugCallV2 通过 R12 报告的状态，见 runtime/asm_amd64.s
+const (
+	debugCallFrameReady = 0  // 调用帧已就绪，写入参数后跳转到目标函数
+	debugCallReturned   = 1  // 目标函数已返回，返回值在寄存器和 SP 处的参数帧中
+	debugCallPanicked   = 2  // 目标函数 panic，接口值在 SP 处
+	debugCallFailed     = 8  // 无法注入调用，原因字符串在 SP 处
+	debugCallRestore    = 16 // 恢复寄存器（rip、rsp 除外）后继续，返回到原 PC
+)
+
+// debugCallMaxSteps 恢复阶段单步执行 debugCallV2 剩余指令的上限
+const debugCallMaxSteps = 10000
+
+// abiIntRegs ABIInternal 的整数参数寄存器顺序
+func abiIntRegs(r *syscall.PtraceRegs) []*uint64 {
+	return []*uint64{&r.Rax, &r.Rbx, &r.Rcx, &r.Rdi, &r.Rsi, &r.R8, &r.R9, &r.R10, &r.R11}
+}
+
+// callFunction 按 runtime.debugCallV2 的协议在线程 c.tid 上注入一次函数调用
+func (d *Debugger) callFunction(c *pendingCall) (callOutcome, error) {
+	debugCall, ok := d.Symbols["runtime.debugCallV2"]
+	if !ok {
+		return callOutcome{}, fmt.Errorf("runtime.debugCallV2 not found (requires a program built with Go 1.17 or later)")
+	}
+	saved, err := d.arch.GetRegisters(c.tid)
+	if err != nil {
+		return callOutcome{}, fmt.Errorf("get registers failed: %v", err)
+	}
+	orig := saved.(*amd64Regs).raw
+	fpregs, err := getFPRegs(c.tid)
+	if err != nil {
+		return callOutcome{}, err
+	}
+
+	// R14 是当前 goroutine 的 g，g.stack.lo 位于偏移 0
+	if orig.R14 != 0 {
+		if lo, err := d.ReadMemory(orig.R14, 8); err == nil && orig.Rsp-binary.LittleEndian.Uint64(lo) < 256 {
+			return callOutcome{}, fmt.Errorf("not enough stack space to inject a call")
+		}
+	}
+
+	// 压入当前 PC，在 SP-16 处写入参数帧大小，然后跳转到 debugCallV2
+	regs := orig
+	regs.Rsp -= 8
+	if err := d.writeWord(regs.Rsp, orig.Rip); err != nil {
+		return callOutcome{}, err
+	}
+	if err := d.writeWord(regs.Rsp-16, c.frame.size); err != nil {
+		return callOutcome{}, err
+	}
+	regs.Rip = debugCall
+	if err := syscall.PtraceSetRegs(c.tid, &regs); err != nil {
+		return callOutcome{}, fmt.Errorf("set registers failed: %v", err)
+	}
+	c.saved, c.fpregs = saved, fpregs
+	return d.driveCall(c)
+}
+
+// driveCall 让程序运行并处理 debugCallV2 报告的状态，直到调用结束、寄存器恢复原状。
+// 程序在调用中停下时（被调用的函数命中断点、收到信号，或者其他线程停下），注入的栈帧保持原样，
+// 调用记入 d.call 并返回 errCallStopped，之后 continue 再从这里继续完成调用
+func (d *Debugger) driveCall(c *pendingCall) (callOutcome, error) {
+	d.call = nil
+	d.calling = true
+	defer func() { d.calling = false }()
+	orig := c.saved.(*amd64Regs).raw
+
+	var regs syscall.PtraceRegs
+	for {
+		d.hit, d.stop = nil, nil
+		if err := d.cont(); err != nil {
+			return c.out, err
+		}
+		if !d.IsRunning {
+			return c.out, fmt.Errorf("process exited during the call to %s", c.name)
+		}
+		stopped := d.stop != nil || d.tid != c.tid
+		if !stopped {
+			if err := syscall.PtraceGetRegs(c.tid, &regs); err != nil {
+				return c.out, fmt.Errorf("get registers failed: %v", err)
+			}
+			stopped = !d.inDebugCall(regs.Rip - 1)
+		}
+		if stopped {
+			if d.stop == nil {
+				d.emitStop(Event{Kind: EventStopped, Thread: d.tid, Signal: signalName(int(syscall.SIGTRAP)), Frame: d.locationOf(d.tid)})
+			}
+			d.call = c
+			d.emit(Event{Kind: EventCallStopped, Thread: c.tid, Call: c.name})
+			return c.out, errCallStopped
+		}
+
+		switch regs.R12 {
+		case debugCallFrameReady:
+			// 参数帧从 SP 开始
+			args, data := c.frame.build(regs.Rsp)
+			if err := d.writeMemory(regs.Rsp, data); err != nil {
+				return c.out, err
+			}
+			for i, arg := range args {
+				*abiIntRegs(&regs)[i] = arg
+			}
+			regs.Rsp -= 8
+			if err := d.writeWord(regs.Rsp, regs.Rip); err != nil {
+				return c.out, err
+			}
+			regs.Rip = c.entry
+			if err := syscall.PtraceSetRegs(c.tid, &regs); err != nil {
+				return c.out, fmt.Errorf("set registers failed: %v", err)
+			}
+
+		case debugCallReturned:
+			for _, reg := range abiIntRegs(&regs) {
+				c.out.results = append(c.out.results, *reg)
+			}
+			if c.frame.resEnd > 0 {
+				stack, err := d.readMemory(regs.Rsp, int(c.frame.resEnd))
+				if err != nil {
+					return c.out, err
+				}
+				c.out.stack = stack
+			}
+
+		case debugCallPanicked:
+			words, err := d.readWords(regs.Rsp, 2)
+			if err != nil {
+				return c.out, err
+			}
+			c.out.panicked, c.out.panicType, c.out.panicData = true, words[0], words[1]
+
+		case debugCallFailed:
+			words, err := d.readWords(regs.Rsp, 2)
+			if err != nil {
+				return c.out, err
+			}
+			c.err = fmt.Errorf("cannot call function: %s", d.readGoString(words[0], words[1]))
+
+		case debugCallRestore:
+			pc, sp := regs.Rip, regs.Rsp
+			regs = orig
+			regs.Rip, regs.Rsp = pc, sp
+			if err := syscall.PtraceSetRegs(c.tid, &regs); err != nil {
+				return c.out, fmt.Errorf("set registers failed: %v", err)
+			}
+			if err := setFPRegs(c.tid, c.fpregs); err != nil {
+				return c.out, err
+			}
+			// 单步执行 debugCallV2 剩余的指令，直到返回到原来的 PC
+			for i := 0; regs.Rip != orig.Rip; i++ {
+				if i == debugCallMaxSteps {
+					return c.out, fmt.Errorf("call did not return to 0x%x", orig.Rip)
+				}
+				if err := d.stepThread(); err != nil {
+					return c.out, err
+				}
+				if err := syscall.PtraceGetRegs(c.tid, &regs); err != nil {
+					return c.out, fmt.Errorf("get registers failed: %v", err)
+				}
+			}
+			d.hit = c.hit
+			return c.out, c.err
+
+		default:
+			return c.out, fmt.Errorf("unexpected debug call status %d at 0x%x", regs.R12, regs.Rip)
+		}
+	}
+}
+
+// inDebugCall 判断 pc 是否位于运行时的调用注入代码（debugCallV2、debugCallNN、debugCallPanicked）中
+func (d *Debugger) inDebugCall(pc uint64) bool {
+	fn := d.index.funcByPC(pc)
+	return fn != nil && (strings.HasPrefix(fn.Name, "runtime.debugCall") || strings.HasPrefix(fn.Name, "debugCall"))
+}
+
+// getFPRegs 读取 x87/SSE 寄存器（user_fpregs_struct，512 字节）
+func getFPRegs(tid int) ([]byte, error) {
+	buf := make([]byte, 512)
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, syscall.PTRACE_GETFPREGS, uintptr(tid), 0, uintptr(unsafe.Pointer(&buf[0])), 0, 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("ptrace getfpregs failed: %v", errno)
+	}
+	return buf, nil
+}
+
+// setFPRegs 写回 getFPRegs 保存的寄存器
+func setFPRegs(tid int, buf []byte) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, syscall.PTRACE_SETFPREGS, uintptr(tid), 0, uintptr(unsafe.Pointer(&buf[0])), 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("ptrace setfpregs failed: %v", errno)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux || !amd64
+// +build !linux !amd64
+
+package debugger
+
+const callArgRegs = 0
+
+func (d *Debugger) callFunction(c *pendingCall) (callOutcome, error) {
+	return callOutcome{}, unsupported("call")
+}
+
+func (d *Debugger) driveCall(c *pendingCall) (callOutcome, error) {
+	return callOutcome{}, unsupported("call")
+}
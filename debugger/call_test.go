@@ -0,0 +1,225 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"reflect"
+	"testing"
+)
+
+var (
+	testInt    = &dwarf.IntType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 8, Name: "int"}}}
+	testInt32  = &dwarf.IntType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 4, Name: "int32"}}}
+	testBool   = &dwarf.BoolType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 1, Name: "bool"}}}
+	testFloat  = &dwarf.FloatType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 8, Name: "float64"}}}
+	testPtr    = &dwarf.PtrType{CommonType: dwarf.CommonType{ByteSize: 8, Name: "*uint8"}, Type: testInt}
+	testString = &dwarf.StructType{CommonType: dwarf.CommonType{ByteSize: 16}, StructName: "string", Kind: "struct", Field: []*dwarf.StructField{
+		{Name: "str", Type: testPtr, ByteOffset: 0},
+		{Name: "len", Type: testInt, ByteOffset: 8},
+	}}
+)
+
+func testArray(elem dwarf.Type, n int64) *dwarf.ArrayType {
+	return &dwarf.ArrayType{CommonType: dwarf.CommonType{ByteSize: elem.Size() * n}, Type: elem, Count: n}
+}
+
+func testStruct(name string, fields ...dwarf.Type) *dwarf.StructType {
+	t := &dwarf.StructType{StructName: name, Kind: "struct"}
+	var off uint64
+	for i, f := range fields {
+		off = alignUp(off, typeAlign(f))
+		t.Field = append(t.Field, &dwarf.StructField{Name: string(rune('A' + i)), Type: f, ByteOffset: int64(off)})
+		off += uint64(f.Size())
+	}
+	t.ByteSize = int64(alignUp(off, typeAlign(t)))
+	return t
+}
+
+func TestRegWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     dwarf.Type
+		n       int
+		ok      bool
+		wantErr bool
+	}{
+		{name: "int", typ: testInt, n: 1, ok: true},
+		{name: "bool", typ: testBool, n: 1, ok: true},
+		{name: "string", typ: testString, n: 2, ok: true},
+		{name: "struct{string; int}", typ: testStruct("main.User", testString, testInt), n: 3, ok: true},
+		{name: "[0]int", typ: testArray(testInt, 0), n: 0, ok: true},
+		{name: "[1]int", typ: testArray(testInt, 1), n: 1, ok: true},
+		// 长度大于 1 的数组只能放在栈上
+		{name: "[3]int", typ: testArray(testInt, 3), ok: false},
+		{name: "struct{[2]int}", typ: testStruct("main.Pair", testArray(testInt, 2)), ok: false},
+		{name: "float64", typ: testFloat, wantErr: true},
+		{name: "struct{int; float64}", typ: testStruct("main.Mixed", testInt, testFloat), wantErr: true},
+	}
+	for _, tt := range tests {
+		n, ok, err := regWords(tt.typ)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("regWords(%s) = %d, %v, want error", tt.name, n, ok)
+			}
+			continue
+		}
+		if err != nil || ok != tt.ok || ok && n != tt.n {
+			t.Errorf("regWords(%s) = %d, %v, %v, want %d, %v", tt.name, n, ok, err, tt.n, tt.ok)
+		}
+	}
+}
+
+func TestNewCallFrame(t *testing.T) {
+	if callArgRegs == 0 {
+		t.Skip("call is not supported on this platform")
+	}
+	str := "hello"
+	ints := func(n int) ([]callParam, []CallArg) {
+		var params []callParam
+		var args []CallArg
+		for i := 0; i < n; i++ {
+			params = append(params, callParam{name: "p", typ: testInt})
+			args = append(args, CallArg{Value: uint64(i + 1)})
+		}
+		return params, args
+	}
+	sum12, sum12Args := ints(12)
+
+	tests := []struct {
+		name    string
+		params  []callParam
+		results []callParam
+		args    []CallArg
+		sp      uint64
+		// 期望的布局
+		regs    []uint64
+		stack   []byte
+		slots   []abiSlot
+		resEnd  uint64
+		size    uint64
+		wantErr bool
+	}{
+		{
+			// greet(name string, times int) string：字符串内容放在溢出区之后
+			name:    "string and int",
+			params:  []callParam{{name: "name", typ: testString}, {name: "times", typ: testInt}},
+			results: []callParam{{typ: testString}},
+			args:    []CallArg{{String: &str}, {Value: 2}},
+			sp:      0x1000,
+			regs:    []uint64{0x1000 + 24, 5, 2},
+			slots:   []abiSlot{{reg: 0}},
+			size:    32,
+		},
+		{
+			// 9 个整数寄存器用完后，其余参数依次放在栈上
+			name:    "stack arguments",
+			params:  sum12,
+			results: []callParam{{typ: testInt}},
+			args:    sum12Args,
+			regs:    []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			stack:   []byte{10, 0, 0, 0, 0, 0, 0, 0, 11, 0, 0, 0, 0, 0, 0, 0, 12, 0, 0, 0, 0, 0, 0, 0},
+			slots:   []abiSlot{{reg: 0}},
+			resEnd:  24,
+			size:    24 + 9*8,
+		},
+		{
+			// triple([3]int) [3]int：数组参数和返回值都在栈上，返回值跟在参数之后
+			name:    "array on the stack",
+			params:  []callParam{{name: "a", typ: testArray(testInt, 3)}},
+			results: []callParam{{typ: testArray(testInt, 3)}},
+			args:    []CallArg{{Data: []byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0}, Type: testArray(testInt, 3)}},
+			stack:   []byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0},
+			slots:   []abiSlot{{reg: -1, off: 24}},
+			resEnd:  48,
+			size:    48,
+		},
+		{
+			// 结构体按字段拆开放进寄存器，int32 变量按值转换为 int
+			name:    "struct and converted integer",
+			params:  []callParam{{name: "u", typ: testStruct("main.User", testString, testInt)}, {name: "n", typ: testInt}},
+			results: []callParam{{typ: testInt}, {typ: testInt}},
+			args: []CallArg{
+				{Data: []byte{0x10, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 30, 0, 0, 0, 0, 0, 0, 0}, Type: testStruct("main.User", testString, testInt)},
+				{Data: []byte{0xff, 0xff, 0xff, 0xff}, Type: testInt32},
+			},
+			regs:  []uint64{0x10, 3, 30, 0xffffffffffffffff},
+			slots: []abiSlot{{reg: 0}, {reg: 1}},
+			size:  32,
+		},
+		{
+			name:    "string literal for an int",
+			params:  []callParam{{name: "n", typ: testInt}},
+			args:    []CallArg{{String: &str}},
+			wantErr: true,
+		},
+		{
+			name:    "float argument",
+			params:  []callParam{{name: "f", typ: testFloat}},
+			args:    []CallArg{{Value: 1}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		f, err := newCallFrame(tt.params, tt.results, tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: newCallFrame succeeded, want error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: newCallFrame: %v", tt.name, err)
+			continue
+		}
+		regs, data := f.build(tt.sp)
+		if !reflect.DeepEqual(regs, tt.regs) {
+			t.Errorf("%s: registers = %#x, want %#x", tt.name, regs, tt.regs)
+		}
+		if !reflect.DeepEqual(f.results, tt.slots) {
+			t.Errorf("%s: result slots = %+v, want %+v", tt.name, f.results, tt.slots)
+		}
+		if f.resEnd != tt.resEnd || f.size != tt.size || uint64(len(data)) != tt.size {
+			t.Errorf("%s: resEnd = %d, size = %d (frame %d bytes), want %d, %d", tt.name, f.resEnd, f.size, len(data), tt.resEnd, tt.size)
+		}
+		if len(tt.stack) > 0 && !reflect.DeepEqual(data[:len(tt.stack)], tt.stack) {
+			t.Errorf("%s: stack arguments = %v, want %v", tt.name, data[:len(tt.stack)], tt.stack)
+		}
+		for _, s := range f.strings {
+			if got := string(data[s.off : s.off+uint64(len(s.data))]); got != string(s.data) {
+				t.Errorf("%s: string data at %d = %q, want %q", tt.name, s.off, got, s.data)
+			}
+		}
+	}
+}
+
+func TestParseCall(t *testing.T) {
+	tests := []struct {
+		expr    string
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{expr: "main.fibonacci(10)", name: "main.fibonacci", args: []string{"10"}},
+		{expr: "  main.f()  ", name: "main.f"},
+		{expr: "main.(*Point).String(p)", name: "main.(*Point).String", args: []string{"p"}},
+		{expr: `main.greet("a, b", 2)`, name: "main.greet", args: []string{`"a, b"`, "2"}},
+		{expr: `main.greet("a)", ')')`, name: "main.greet", args: []string{`"a)"`, `')'`}},
+		{expr: "main.greet(`x\\`, 1)", name: "main.greet", args: []string{"`x\\`", "1"}},
+		{expr: "main.f(g(1, 2), [2]int{1, 2})", name: "main.f", args: []string{"g(1, 2)", "[2]int{1, 2}"}},
+		{expr: "main.f", wantErr: true},
+		{expr: "(1)", wantErr: true},
+		{expr: "main.f(1,)", wantErr: true},
+		{expr: `main.f("abc)`, wantErr: true},
+	}
+	for _, tt := range tests {
+		name, args, err := parseCall(tt.expr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCall(%q) = %q, %q, want error", tt.expr, name, args)
+			}
+			continue
+		}
+		if err != nil || name != tt.name || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("parseCall(%q) = %q, %q, %v, want %q, %q", tt.expr, name, args, err, tt.name, tt.args)
+		}
+	}
+}
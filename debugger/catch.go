@@ -0,0 +1,205 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CatchKind 捕获点等待的事件
+type CatchKind string
+
+const (
+	CatchSyscall CatchKind = "syscall"
+	CatchPanic   CatchKind = "panic"
+)
+
+// Catchpoint 在事件而不是代码地址上暂停程序，与断点共用编号，用 delete 删除
+type Catchpoint struct {
+	ID       int
+	Kind     CatchKind
+	Syscalls []int // 捕获的系统调用号，为空时捕获所有系统调用
+	Enabled  bool
+	HitCount int
+
+	addrs map[uint64]string // panic 捕获点设置的内部断点地址及其所在的运行时函数
+}
+
+// String 捕获点等待的事件，例如 "syscall openat write"
+func (c *Catchpoint) String() string {
+	if c.Kind != CatchSyscall {
+		return string(c.Kind)
+	}
+	if len(c.Syscalls) == 0 {
+		return "syscall (any)"
+	}
+	names := make([]string, len(c.Syscalls))
+	for i, nr := range c.Syscalls {
+		names[i] = syscallName(nr)
+	}
+	return "syscall " + strings.Join(names, " ")
+}
+
+// syscallCall 线程正在执行的系统调用
+type syscallCall struct {
+	nr   int
+	args [6]uint64
+}
+
+// CatchSyscalls 添加系统调用捕获点，names 为系统调用的名字或编号，为空时捕获所有系统调用
+func (d *Debugger) CatchSyscalls(names []string) (*Catchpoint, error) {
+	if len(syscallNames) == 0 {
+		return nil, unsupported("catch syscall")
+	}
+	var nrs []int
+	for _, name := range names {
+		nr, err := syscallNumber(name)
+		if err != nil {
+			return nil, err
+		}
+		nrs = append(nrs, nr)
+	}
+	return d.addCatchpoint(CatchSyscall, nrs), nil
+}
+
+func (d *Debugger) addCatchpoint(kind CatchKind, syscalls []int) *Catchpoint {
+	d.nextBreakpointID++
+	c := &Catchpoint{ID: d.nextBreakpointID, Kind: kind, Syscalls: syscalls, Enabled: true}
+	d.Catchpoints = append(d.Catchpoints, c)
+	return c
+}
+
+// copyCatchpoints restart 时沿用原来的捕获点（在 Launch 之前调用），默认的 panic 捕获点保持编号 0
+func (d *Debugger) copyCatchpoints(old []*Catchpoint) {
+	d.Catchpoints = nil
+	for _, c := range old {
+		if c.ID == 0 {
+			d.Catchpoints = append(d.Catchpoints, &Catchpoint{Kind: c.Kind, Enabled: c.Enabled})
+		} else {
+			d.addCatchpoint(c.Kind, c.Syscalls)
+		}
+	}
+}
+
+// CatchpointByID 按编号查找捕获点
+func (d *Debugger) CatchpointByID(id int) *Catchpoint {
+	for _, c := range d.Catchpoints {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// DeleteCatchpoint 删除捕获点
+func (d *Debugger) DeleteCatchpoint(id int) error {
+	for i, c := range d.Catchpoints {
+		if c.ID == id {
+			if err := d.uninstallCatchpoint(c); err != nil {
+				return err
+			}
+			d.Catchpoints = append(d.Catchpoints[:i], d.Catchpoints[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no catchpoint number %d", id)
+}
+
+// catchingSyscalls 是否有启用的系统调用捕获点，这时线程以 PTRACE_SYSCALL 恢复运行
+func (d *Debugger) catchingSyscalls() bool {
+	for _, c := range d.Catchpoints {
+		if c.Enabled && c.Kind == CatchSyscall {
+			return true
+		}
+	}
+	return false
+}
+
+// syscallCatchpoint 返回捕获系统调用 nr 的第一个启用的捕获点
+func (d *Debugger) syscallCatchpoint(nr int) *Catchpoint {
+	for _, c := range d.Catchpoints {
+		if !c.Enabled || c.Kind != CatchSyscall {
+			continue
+		}
+		if len(c.Syscalls) == 0 {
+			return c
+		}
+		for _, n := range c.Syscalls {
+			if n == nr {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// syscallName 系统调用的名字，未知的编号显示为 syscall_<nr>
+func syscallName(nr int) string {
+	if name, ok := syscallNames[nr]; ok {
+		return name
+	}
+	return fmt.Sprintf("syscall_%d", nr)
+}
+
+// syscallNumber 按名字或编号查找系统调用
+func syscallNumber(name string) (int, error) {
+	if nr, err := strconv.Atoi(name); err == nil {
+		if nr < 0 {
+			return 0, fmt.Errorf("invalid syscall number: %d", nr)
+		}
+		return nr, nil
+	}
+	for nr, n := range syscallNames {
+		if n == name {
+			return nr, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown syscall: %s", name)
+}
+
+// catchpointInfo info breakpoints 中列出的捕获点
+type catchpointInfo struct {
+	ID       int    `json:"id"`
+	What     string `json:"what"`
+	Enabled  bool   `json:"enabled"`
+	HitCount int    `json:"hit_count"`
+}
+
+// catchpointInfos 按编号顺序列出捕获点
+func (d *Debugger) catchpointInfos() []catchpointInfo {
+	var infos []catchpointInfo
+	for _, c := range d.Catchpoints {
+		infos = append(infos, catchpointInfo{ID: c.ID, What: c.String(), Enabled: c.Enabled, HitCount: c.HitCount})
+	}
+	return infos
+}
+
+// catchResult catch 添加的捕获点
+type catchResult struct {
+	ID   int    `json:"id"`
+	What string `json:"what"`
+}
+
+func (res catchResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Catchpoint %d (%s)\n", res.ID, res.What)
+}
+
+// cmdCatch catch syscall [name|number...] 或 catch panic
+func (r *REPL) cmdCatch(args []string) error {
+	var c *Catchpoint
+	var err error
+	switch {
+	case len(args) > 0 && args[0] == string(CatchSyscall):
+		c, err = r.Debugger.CatchSyscalls(args[1:])
+	case len(args) == 1 && args[0] == string(CatchPanic):
+		c, err = r.Debugger.CatchPanics()
+	default:
+		return fmt.Errorf("usage: catch syscall [name|number...] | catch panic")
+	}
+	if err != nil {
+		return err
+	}
+	r.output(catchResult{ID: c.ID, What: c.String()})
+	return nil
+}
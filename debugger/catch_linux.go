@@ -0,0 +1,295 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package debugger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// syscallTrap PTRACE_O_TRACESYSGOOD 下系统调用停止报告的信号，与断点的 SIGTRAP 区分开
+const syscallTrap = syscall.SIGTRAP | 0x80
+
+const (
+	ptraceGetSyscallInfo = 0x420e // PTRACE_GET_SYSCALL_INFO，linux 5.3 起支持
+	syscallInfoEntry     = 1
+	syscallInfoExit      = 2
+)
+
+// ptraceSyscallInfo struct ptrace_syscall_info
+type ptraceSyscallInfo struct {
+	Op   uint8
+	_    [3]uint8
+	Arch uint32
+	IP   uint64
+	SP   uint64
+	// 进入时为系统调用号和 6 个参数，返回时第一个为返回值
+	Data [8]uint64
+}
+
+// trackSyscall 处理线程的系统调用停止：进入时记录系统调用，返回时取出进入时记录的系统调用。
+// 返回 nil 表示不知道是哪个系统调用（捕获点是在系统调用执行期间设置的）。
+func (d *Debugger) trackSyscall(th *thread) (call *syscallCall, exit bool, ret int64, err error) {
+	var info ptraceSyscallInfo
+	_, _, errno := syscall.Syscall6(syscall.SYS_PTRACE, ptraceGetSyscallInfo, uintptr(th.tid),
+		unsafe.Sizeof(info), uintptr(unsafe.Pointer(&info)), 0, 0)
+	if errno != 0 {
+		return nil, false, 0, fmt.Errorf("ptrace get_syscall_info failed (requires linux 5.3 or later): %v", errno)
+	}
+	switch info.Op {
+	case syscallInfoEntry:
+		call = &syscallCall{nr: int(info.Data[0])}
+		copy(call.args[:], info.Data[1:7])
+		th.syscall = call
+		return call, false, 0, nil
+	case syscallInfoExit:
+		call, th.syscall = th.syscall, nil
+		return call, true, int64(info.Data[0]), nil
+	}
+	return nil, false, 0, nil
+}
+
+// syscallEvent 命中系统调用捕获点的事件，参数在停止时从程序内存中解码
+func (d *Debugger) syscallEvent(c *Catchpoint, tid int, call *syscallCall, exit bool, ret int64) Event {
+	event := Event{Kind: EventSyscallEntry, Thread: tid, Catchpoint: c.ID, Syscall: syscallName(call.nr)}
+	event.Call = d.formatSyscall(tid, call, exit, ret)
+	if exit {
+		event.Kind = EventSyscallReturn
+		event.Return = formatSyscallReturn(ret)
+	}
+	event.Frame = d.locationOf(tid)
+	return event
+}
+
+// syscallFormats 常见系统调用的参数格式，每个字符对应一个参数：
+// f 文件描述符，F 目录文件描述符，p 路径，o open 的标志，m 权限，
+// w 写出的数据（长度为下一个参数），r 读入的数据（返回时显示，长度为返回值），
+// s 套接字地址（长度为下一个参数），d 十进制整数，x 十六进制。
+// 不在表中的系统调用显示全部 6 个十六进制参数。
+var syscallFormats = map[string]string{
+	"open": "pom", "openat": "Fpom", "creat": "pm",
+	"read": "frd", "pread64": "frdd", "write": "fwd", "pwrite64": "fwdd",
+	"close": "f", "dup": "f", "dup2": "ff", "dup3": "ffx", "fsync": "f", "fchdir": "f",
+	"lseek": "fdd", "ftruncate": "fd", "truncate": "pd", "fstat": "fx",
+	"stat": "px", "lstat": "px", "newfstatat": "Fpxx", "statx": "Fpxxx",
+	"access": "pd", "faccessat": "Fpd", "faccessat2": "Fpdx",
+	"unlink": "p", "unlinkat": "Fpx", "rmdir": "p", "mkdir": "pm", "mkdirat": "Fpm",
+	"chdir": "p", "chmod": "pm", "fchmodat": "Fpm", "readlink": "pxd", "readlinkat": "Fpxd",
+	"rename": "pp", "renameat": "FpFp", "renameat2": "FpFpx", "link": "pp", "symlink": "pp",
+	"execve": "pxx", "execveat": "Fpxxx",
+	"socket": "ddd", "connect": "fsd", "bind": "fsd", "listen": "fd",
+	"accept": "fxx", "accept4": "fxxx", "sendto": "fwdxsd", "recvfrom": "frdxxx",
+	"exit": "d", "exit_group": "d", "kill": "dd", "tgkill": "ddd",
+}
+
+// formatSyscall 按 syscallFormats 解码系统调用的参数，例如 openat(AT_FDCWD, "/etc/hosts", O_RDONLY|O_CLOEXEC)
+func (d *Debugger) formatSyscall(tid int, call *syscallCall, exit bool, ret int64) string {
+	name := syscallName(call.nr)
+	format, ok := syscallFormats[name]
+	if !ok {
+		format = "xxxxxx"
+	}
+	var args []string
+	for i, c := range format {
+		a := call.args[i]
+		var next uint64
+		if i+1 < len(call.args) {
+			next = call.args[i+1]
+		}
+		switch c {
+		case 'f':
+			args = append(args, strconv.Itoa(int(int32(a))))
+		case 'F':
+			if int32(a) == atFDCWD {
+				args = append(args, "AT_FDCWD")
+			} else {
+				args = append(args, strconv.Itoa(int(int32(a))))
+			}
+		case 'p':
+			args = append(args, d.readCString(tid, a))
+		case 'o':
+			args = append(args, formatOpenFlags(a))
+		case 'm':
+			// 权限只在创建文件时有意义
+			if i > 0 && format[i-1] == 'o' && call.args[i-1]&syscall.O_CREAT == 0 {
+				continue
+			}
+			args = append(args, fmt.Sprintf("%#o", a))
+		case 'w':
+			args = append(args, d.readData(tid, a, int64(next)))
+		case 'r':
+			if exit && ret > 0 {
+				args = append(args, d.readData(tid, a, ret))
+			} else {
+				args = append(args, fmt.Sprintf("0x%x", a))
+			}
+		case 's':
+			args = append(args, d.readSockaddr(tid, a, next))
+		case 'd':
+			args = append(args, strconv.FormatInt(int64(a), 10))
+		default:
+			args = append(args, fmt.Sprintf("0x%x", a))
+		}
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+const atFDCWD = -100
+
+// syscallDataLimit 显示的字符串和数据的最大长度
+const syscallDataLimit = 64
+
+// readCString 读取程序内存中以 0 结尾的字符串，按页分段读取以免越过未映射的页
+func (d *Debugger) readCString(tid int, addr uint64) string {
+	if addr == 0 {
+		return "NULL"
+	}
+	var s []byte
+	for len(s) < 4*syscallDataLimit {
+		n := 4096 - int(addr%4096)
+		if n > syscallDataLimit {
+			n = syscallDataLimit
+		}
+		buf := make([]byte, n)
+		if _, err := syscall.PtracePeekData(tid, uintptr(addr), buf); err != nil {
+			if len(s) == 0 {
+				return fmt.Sprintf("0x%x", addr)
+			}
+			break
+		}
+		if i := strings.IndexByte(string(buf), 0); i >= 0 {
+			return strconv.Quote(string(append(s, buf[:i]...)))
+		}
+		s = append(s, buf...)
+		addr += uint64(n)
+	}
+	return strconv.Quote(string(s)) + "..."
+}
+
+// readData 读取系统调用读写的数据，只显示开头的一部分
+func (d *Debugger) readData(tid int, addr uint64, size int64) string {
+	if size <= 0 {
+		return `""`
+	}
+	n := size
+	if n > syscallDataLimit {
+		n = syscallDataLimit
+	}
+	buf := make([]byte, n)
+	if _, err := syscall.PtracePeekData(tid, uintptr(addr), buf); err != nil {
+		return fmt.Sprintf("0x%x", addr)
+	}
+	s := strconv.Quote(string(buf))
+	if n < size {
+		s += "..."
+	}
+	return s
+}
+
+// readSockaddr 解码 AF_INET、AF_INET6 和 AF_UNIX 的套接字地址
+func (d *Debugger) readSockaddr(tid int, addr, size uint64) string {
+	if addr == 0 {
+		return "NULL"
+	}
+	if size < 2 || size > 128 {
+		return fmt.Sprintf("0x%x", addr)
+	}
+	buf := make([]byte, size)
+	if _, err := syscall.PtracePeekData(tid, uintptr(addr), buf); err != nil {
+		return fmt.Sprintf("0x%x", addr)
+	}
+	// sa_family 是本机字节序，端口是网络字节序
+	switch family := binary.LittleEndian.Uint16(buf); {
+	case family == syscall.AF_INET && len(buf) >= 8:
+		return fmt.Sprintf("{AF_INET, %s:%d}", net.IP(buf[4:8]), binary.BigEndian.Uint16(buf[2:4]))
+	case family == syscall.AF_INET6 && len(buf) >= 24:
+		return fmt.Sprintf("{AF_INET6, [%s]:%d}", net.IP(buf[8:24]), binary.BigEndian.Uint16(buf[2:4]))
+	case family == syscall.AF_UNIX:
+		path := buf[2:]
+		if len(path) > 0 && path[0] == 0 {
+			// 抽象命名空间的地址以 0 开头，长度由 addrlen 决定
+			return fmt.Sprintf("{AF_UNIX, %s}", strconv.Quote("@"+string(path[1:])))
+		}
+		if i := strings.IndexByte(string(path), 0); i >= 0 {
+			path = path[:i]
+		}
+		return fmt.Sprintf("{AF_UNIX, %s}", strconv.Quote(string(path)))
+	default:
+		return fmt.Sprintf("{family %d}", family)
+	}
+}
+
+var openFlagNames = []struct {
+	flag uint64
+	name string
+}{
+	{syscall.O_CREAT, "O_CREAT"},
+	{syscall.O_EXCL, "O_EXCL"},
+	{syscall.O_NOCTTY, "O_NOCTTY"},
+	{syscall.O_TRUNC, "O_TRUNC"},
+	{syscall.O_APPEND, "O_APPEND"},
+	{syscall.O_NONBLOCK, "O_NONBLOCK"},
+	{syscall.O_DIRECTORY, "O_DIRECTORY"},
+	{syscall.O_NOFOLLOW, "O_NOFOLLOW"},
+	{syscall.O_CLOEXEC, "O_CLOEXEC"},
+}
+
+// formatOpenFlags open 的标志，例如 O_WRONLY|O_CREAT|O_TRUNC
+func formatOpenFlags(flags uint64) string {
+	names := []string{"O_RDONLY"}
+	switch flags & syscall.O_ACCMODE {
+	case syscall.O_WRONLY:
+		names[0] = "O_WRONLY"
+	case syscall.O_RDWR:
+		names[0] = "O_RDWR"
+	}
+	rest := flags &^ syscall.O_ACCMODE
+	for _, f := range openFlagNames {
+		if rest&f.flag != 0 {
+			names = append(names, f.name)
+			rest &^= f.flag
+		}
+	}
+	if rest != 0 {
+		names = append(names, fmt.Sprintf("0x%x", rest))
+	}
+	return strings.Join(names, "|")
+}
+
+var errnoNames = map[syscall.Errno]string{
+	syscall.EPERM: "EPERM", syscall.ENOENT: "ENOENT", syscall.ESRCH: "ESRCH", syscall.EINTR: "EINTR",
+	syscall.EIO: "EIO", syscall.EBADF: "EBADF", syscall.ECHILD: "ECHILD", syscall.EAGAIN: "EAGAIN",
+	syscall.ENOMEM: "ENOMEM", syscall.EACCES: "EACCES", syscall.EFAULT: "EFAULT", syscall.EBUSY: "EBUSY",
+	syscall.EEXIST: "EEXIST", syscall.EXDEV: "EXDEV", syscall.ENOTDIR: "ENOTDIR", syscall.EISDIR: "EISDIR",
+	syscall.EINVAL: "EINVAL", syscall.EMFILE: "EMFILE", syscall.ENOTTY: "ENOTTY", syscall.ENOSPC: "ENOSPC",
+	syscall.ESPIPE: "ESPIPE", syscall.EROFS: "EROFS", syscall.EPIPE: "EPIPE", syscall.ERANGE: "ERANGE",
+	syscall.ENAMETOOLONG: "ENAMETOOLONG", syscall.ENOSYS: "ENOSYS", syscall.ENOTEMPTY: "ENOTEMPTY",
+	syscall.ELOOP: "ELOOP", syscall.ENOTSOCK: "ENOTSOCK", syscall.EADDRINUSE: "EADDRINUSE",
+	syscall.ENETUNREACH: "ENETUNREACH", syscall.ECONNRESET: "ECONNRESET", syscall.ENOTCONN: "ENOTCONN",
+	syscall.ETIMEDOUT: "ETIMEDOUT", syscall.ECONNREFUSED: "ECONNREFUSED", syscall.EHOSTUNREACH: "EHOSTUNREACH",
+	syscall.EALREADY: "EALREADY", syscall.EINPROGRESS: "EINPROGRESS",
+}
+
+// formatSyscallReturn 系统调用的返回值，出错时与 strace 一样显示为 -1 和 errno
+func formatSyscallReturn(ret int64) string {
+	if ret < 0 && ret >= -4095 {
+		errno := syscall.Errno(-ret)
+		name, ok := errnoNames[errno]
+		if !ok {
+			name = fmt.Sprintf("errno %d", -ret)
+		}
+		return fmt.Sprintf("-1 %s (%s)", name, errno.Error())
+	}
+	if ret > 0xffffffff || ret < 0 {
+		// mmap、brk 等返回地址
+		return fmt.Sprintf("0x%x", uint64(ret))
+	}
+	return strconv.FormatInt(ret, 10)
+}
@@ -0,0 +1,131 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Checkpoint 用 fork 保存的进程快照。快照进程一直处于 ptrace 暂停状态，
+// 其中不含断点指令，恢复时再写入当时的断点。
+type Checkpoint struct {
+	ID    int    `json:"id"`
+	PID   int    `json:"pid"`
+	Frame *Frame `json:"frame,omitempty"`
+}
+
+// Checkpoint 在当前位置保存进程快照
+func (d *Debugger) Checkpoint() (*Checkpoint, error) {
+	if !d.IsRunning {
+		return nil, errNotRunning
+	}
+	if err := d.callPending(); err != nil {
+		return nil, err
+	}
+	child, err := d.forkTracee(d.tid)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint failed: %v", err)
+	}
+	for _, bp := range d.Breakpoints {
+		if !bp.Enabled {
+			continue
+		}
+		if err := writeProcessMemory(child, bp.Address, bp.Original); err != nil {
+			return nil, fmt.Errorf("checkpoint failed: %v", err)
+		}
+	}
+	d.nextCheckpointID++
+	cp := &Checkpoint{ID: d.nextCheckpointID, PID: child, Frame: d.locationOf(d.tid)}
+	d.checkpoints = append(d.checkpoints, cp)
+	return cp, nil
+}
+
+// Checkpoints 返回所有检查点
+func (d *Debugger) Checkpoints() []*Checkpoint {
+	return d.checkpoints
+}
+
+// Restart 回到检查点 id：从快照再 fork 出一个进程取代当前进程，快照本身保持不变，可以多次恢复
+func (d *Debugger) Restart(id int) error {
+	var cp *Checkpoint
+	for _, c := range d.checkpoints {
+		if c.ID == id {
+			cp = c
+		}
+	}
+	if cp == nil {
+		return fmt.Errorf("no checkpoint number %d", id)
+	}
+	child, err := d.forkTracee(cp.PID)
+	if err != nil {
+		return fmt.Errorf("restart failed: %v", err)
+	}
+	if d.IsRunning {
+		d.killTracee()
+	}
+	if err := d.switchProcess(child); err != nil {
+		return err
+	}
+	d.hit = nil
+	d.stopSeq++
+	d.record = nil // 记录的历史属于被替换的进程
+	d.call = nil
+	for _, bp := range d.Breakpoints {
+		if !bp.Enabled {
+			continue
+		}
+		if _, err := d.insertBreakpoint(bp.Address); err != nil {
+			return err
+		}
+	}
+	d.emit(Event{Kind: EventRestarted, PID: child, Checkpoint: id, Frame: d.locationOf(child)})
+	return nil
+}
+
+type checkpointsResult struct {
+	Checkpoints []*Checkpoint `json:"checkpoints"`
+}
+
+func (res checkpointsResult) printText(w io.Writer) {
+	if len(res.Checkpoints) == 0 {
+		fmt.Fprintln(w, "No checkpoints.")
+		return
+	}
+	for _, cp := range res.Checkpoints {
+		where := "?"
+		if cp.Frame != nil {
+			where = cp.Frame.String()
+		}
+		fmt.Fprintf(w, "%d  process %d at %s\n", cp.ID, cp.PID, where)
+	}
+}
+
+// cmdCheckpoint checkpoint 保存当前进程的快照
+func (r *REPL) cmdCheckpoint(args []string) error {
+	cp, err := r.Debugger.Checkpoint()
+	if err != nil {
+		return err
+	}
+	r.output(messageResult{Message: fmt.Sprintf("Checkpoint %d: fork returned pid %d", cp.ID, cp.PID)})
+	return nil
+}
+
+// cmdCheckpoints checkpoints 列出所有检查点
+func (r *REPL) cmdCheckpoints(args []string) error {
+	r.output(checkpointsResult{Checkpoints: r.Debugger.Checkpoints()})
+	return nil
+}
+
+// restartCheckpoint restart <id> 回到检查点
+func (r *REPL) restartCheckpoint(arg string) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint number: %s", arg)
+	}
+	if err := r.Debugger.Restart(id); err != nil {
+		return err
+	}
+	r.onStop()
+	r.output(r.stopResult())
+	return nil
+}
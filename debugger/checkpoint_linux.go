@@ -0,0 +1,155 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// forkTracee 在线程 tid 上注入一次 fork 系统调用。子进程通过 PTRACE_O_TRACEFORK（见 ptraceOptions）自动被跟踪，
+// 返回时父子进程的指令和寄存器都已恢复到注入前的状态，子进程保持暂停。
+// 与 gdb 的 checkpoint 相同，子进程只包含执行 fork 的那一个线程。
+func (d *Debugger) forkTracee(tid int) (int, error) {
+	saved, err := d.arch.GetRegisters(tid)
+	if err != nil {
+		return 0, fmt.Errorf("get registers failed: %v", err)
+	}
+	regs, err := d.arch.GetRegisters(tid)
+	if err != nil {
+		return 0, fmt.Errorf("get registers failed: %v", err)
+	}
+	pc := saved.PC()
+	orig := make([]byte, len(forkInstr))
+	if _, err := syscall.PtracePeekData(tid, uintptr(pc), orig); err != nil {
+		return 0, fmt.Errorf("peek data failed: %v", err)
+	}
+
+	// restore 恢复进程 pid 中被改写的指令和寄存器
+	restore := func(pid int) error {
+		if _, err := syscall.PtracePokeData(pid, uintptr(pc), orig); err != nil {
+			return fmt.Errorf("restore instruction failed: %v", err)
+		}
+		if err := d.arch.SetRegisters(pid, saved); err != nil {
+			return fmt.Errorf("restore registers failed: %v", err)
+		}
+		return nil
+	}
+
+	if _, err := syscall.PtracePokeData(tid, uintptr(pc), forkInstr); err != nil {
+		return 0, fmt.Errorf("poke data failed: %v", err)
+	}
+	prepareFork(regs)
+	if err := d.arch.SetRegisters(tid, regs); err != nil {
+		restore(tid)
+		return 0, fmt.Errorf("set registers failed: %v", err)
+	}
+
+	// 单步执行系统调用：先收到 PTRACE_EVENT_FORK，再收到单步完成的 SIGTRAP
+	child := 0
+	for {
+		if err := syscall.PtraceSingleStep(tid); err != nil {
+			restore(tid)
+			return 0, fmt.Errorf("ptrace singlestep failed: %v", err)
+		}
+		var ws syscall.WaitStatus
+		if _, err := syscall.Wait4(tid, &ws, syscall.WALL, nil); err != nil {
+			return 0, fmt.Errorf("wait4 failed: %v", err)
+		}
+		if ws.Exited() || ws.Signaled() {
+			return 0, fmt.Errorf("process %d exited during fork", tid)
+		}
+		sig := ws.StopSignal()
+		if sig == syscall.SIGTRAP && ws.TrapCause() == syscall.PTRACE_EVENT_FORK {
+			msg, err := syscall.PtraceGetEventMsg(tid)
+			if err != nil {
+				restore(tid)
+				return 0, fmt.Errorf("ptrace geteventmsg failed: %v", err)
+			}
+			child = int(msg)
+			continue
+		}
+		if sig == syscall.SIGTRAP {
+			break
+		}
+		// 系统调用执行前收到的信号留到程序恢复运行时再注入
+		if th := d.threads[tid]; th != nil && d.Signals.Policy(int(sig)).Pass {
+			th.pendingSig = sig
+		}
+	}
+
+	if child == 0 {
+		after, err := d.arch.GetRegisters(tid)
+		restore(tid)
+		if err != nil {
+			return 0, fmt.Errorf("get registers failed: %v", err)
+		}
+		return 0, fmt.Errorf("fork failed: %v", syscall.Errno(-forkResult(after)))
+	}
+	if err := restore(tid); err != nil {
+		return 0, err
+	}
+
+	// 子进程以 SIGSTOP 开始运行，它的内存是注入时的副本，同样需要恢复
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(child, &ws, syscall.WALL, nil); err != nil {
+		return 0, fmt.Errorf("wait4 %d failed: %v", child, err)
+	}
+	if err := restore(child); err != nil {
+		syscall.Kill(child, syscall.SIGKILL)
+		return 0, err
+	}
+	return child, nil
+}
+
+// killTracee 杀死当前进程并回收所有线程，用于 kill 和切换到检查点
+func (d *Debugger) killTracee() error {
+	if err := d.Process.Kill(); err != nil {
+		return err
+	}
+	// 主线程的退出要等其他线程都被回收后才会报告
+	var ws syscall.WaitStatus
+	for tid := range d.threads {
+		if tid != d.Process.Pid {
+			syscall.Wait4(tid, &ws, syscall.WALL, nil)
+		}
+	}
+	syscall.Wait4(d.Process.Pid, &ws, syscall.WALL, nil)
+	d.IsRunning = false
+	d.threads = nil
+	return nil
+}
+
+// killCheckpoints 杀死并回收所有检查点进程
+func (d *Debugger) killCheckpoints() {
+	var ws syscall.WaitStatus
+	for _, cp := range d.checkpoints {
+		syscall.Kill(cp.PID, syscall.SIGKILL)
+		syscall.Wait4(cp.PID, &ws, syscall.WALL, nil)
+	}
+	d.checkpoints = nil
+}
+
+// switchProcess 让调试器接管暂停中的进程 pid（只有一个线程）
+func (d *Debugger) switchProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	d.Process = process
+	d.tid = pid
+	d.threads = map[int]*thread{pid: {tid: pid, stopped: true, started: true}}
+	d.IsRunning = true
+	return nil
+}
+
+// writeProcessMemory 写入进程 pid（不一定是当前进程）的内存
+func writeProcessMemory(pid int, address uint64, data []byte) error {
+	if _, err := syscall.PtracePokeData(pid, uintptr(address), data); err != nil {
+		return fmt.Errorf("write memory of %d at 0x%x failed: %v", pid, address, err)
+	}
+	return nil
+}
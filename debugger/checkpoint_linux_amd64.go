@@ -0,0 +1,19 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package debugger
+
+// syscall
+var forkInstr = []byte{0x0f, 0x05}
+
+// prepareFork 设置 fork(2) 的系统调用号
+func prepareFork(regs registers) {
+	r := &regs.(*amd64Regs).raw
+	r.Orig_rax = ^uint64(0)
+	r.Rax = 57 // SYS_FORK
+}
+
+// forkResult 系统调用的返回值，失败时为负的 errno
+func forkResult(regs registers) int64 {
+	return int64(regs.(*amd64Regs).raw.Rax)
+}
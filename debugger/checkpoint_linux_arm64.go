@@ -0,0 +1,22 @@
+//go:build linux && arm64
+// +build linux,arm64
+
+package debugger
+
+import "syscall"
+
+// SVC #0
+var forkInstr = []byte{0x01, 0x00, 0x00, 0xd4}
+
+// prepareFork arm64 没有 fork(2)，使用 clone(SIGCHLD, 0, 0, 0, 0)
+func prepareFork(regs registers) {
+	r := &regs.(*arm64Regs).raw
+	r.Regs[8] = 220 // SYS_CLONE
+	r.Regs[0] = uint64(syscall.SIGCHLD)
+	r.Regs[1], r.Regs[2], r.Regs[3], r.Regs[4] = 0, 0, 0, 0
+}
+
+// forkResult 系统调用的返回值，失败时为负的 errno
+func forkResult(regs registers) int64 {
+	return int64(regs.(*arm64Regs).raw.Regs[0])
+}
@@ -0,0 +1,29 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package debugger
+
+func (d *Debugger) forkTracee(tid int) (int, error) {
+	return 0, unsupported("checkpoint")
+}
+
+// killTracee 没有检查点时只需要杀死并回收进程
+func (d *Debugger) killTracee() error {
+	if err := d.Process.Kill(); err != nil {
+		return err
+	}
+	d.Process.Wait()
+	d.IsRunning = false
+	d.threads = nil
+	return nil
+}
+
+func (d *Debugger) killCheckpoints() {}
+
+func (d *Debugger) switchProcess(pid int) error {
+	return unsupported("checkpoint")
+}
+
+func writeProcessMemory(pid int, address uint64, data []byte) error {
+	return unsupported("checkpoint")
+}
@@ -0,0 +1,694 @@
+package debugger
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// commandInfo 一条 REPL 命令：名字和别名、帮助信息、所需的后端能力、参数补全以及实现
+type commandInfo struct {
+	names     []string
+	usage     string
+	desc      string
+	supported func(Capabilities) bool
+	// program 需要先 launch 程序
+	program bool
+	// repeat 空行时重复执行（与 gdb 一致）
+	repeat bool
+	// complete 返回参数补全候选
+	complete func(r *REPL, word string) []string
+	run      func(r *REPL, args []string) error
+}
+
+// commandRegistry 按注册顺序保存命令，并按名字和别名索引
+type commandRegistry struct {
+	list   []*commandInfo
+	byName map[string]*commandInfo
+}
+
+func newCommandRegistry() *commandRegistry {
+	return &commandRegistry{byName: make(map[string]*commandInfo)}
+}
+
+// register 注册命令，名字或别名重复属于编程错误
+func (reg *commandRegistry) register(info *commandInfo) {
+	for _, name := range info.names {
+		if _, exists := reg.byName[name]; exists {
+			panic(fmt.Sprintf("debugger: command %q registered twice", name))
+		}
+		reg.byName[name] = info
+	}
+	reg.list = append(reg.list, info)
+}
+
+// lookup 按名字或别名查找命令
+func (reg *commandRegistry) lookup(name string) *commandInfo {
+	return reg.byName[name]
+}
+
+// names 返回所有命令名和别名
+func (reg *commandRegistry) names() []string {
+	var names []string
+	for _, info := range reg.list {
+		names = append(names, info.names...)
+	}
+	return names
+}
+
+// builtinCommands 内置命令，在 init 中注册以避免与 help 之间的初始化循环
+var builtinCommands = newCommandRegistry()
+
+func init() {
+	for _, info := range []*commandInfo{
+		{names: []string{"help", "h"}, desc: "Show this help message",
+			run: (*REPL).cmdHelp},
+		{names: []string{"launch", "l"}, usage: "[--stdin f] [--stdout f] [--stderr f] [--tty dev] [--env K=V] [--cwd dir] <program> [args]",
+			desc:      "Launch a program for debugging (its terminal output is shown with a [program] prefix unless redirected)",
+			supported: func(c Capabilities) bool { return c.Launch }, complete: completePaths,
+			run: (*REPL).cmdLaunch},
+		{names: []string{"input"}, usage: "<text> | --eof", desc: "Send a line of input (or end of file) to the program's terminal",
+			program: true,
+			run:     (*REPL).cmdInput},
+		{names: []string{"continue", "c"}, desc: "Continue execution",
+			supported: func(c Capabilities) bool { return c.Continue }, program: true, repeat: true,
+			run: (*REPL).cmdContinue},
+		{names: []string{"break", "b"}, usage: "<addr|func|file:line> [if <cond>]", desc: "Set a breakpoint, optionally with a condition such as 'i > 3'",
+			supported: func(c Capabilities) bool { return c.Breakpoints }, program: true, complete: (*REPL).completeLocations,
+			run: (*REPL).cmdBreak},
+		{names: []string{"catch"}, usage: "syscall [name|number...] | panic", desc: "Stop when the program enters or returns from the given syscalls (all when none are given), or when it panics (catchpoint 0, on by default)",
+			supported: func(c Capabilities) bool { return c.Catch }, program: true, complete: completeCatch,
+			run: (*REPL).cmdCatch},
+		{names: []string{"delete", "d"}, usage: "<id|addr>", desc: "Remove a breakpoint or catchpoint",
+			supported: func(c Capabilities) bool { return c.Breakpoints }, program: true, complete: (*REPL).completeBreakpoints,
+			run: (*REPL).cmdDelete},
+		{names: []string{"step", "s"}, desc: "Execute one instruction",
+			supported: func(c Capabilities) bool { return c.Step }, program: true, repeat: true,
+			run: (*REPL).cmdStep},
+		{names: []string{"next", "n"}, desc: "Run to the next source line, stepping over calls",
+			supported: func(c Capabilities) bool { return c.Step && c.StackTrace }, program: true, repeat: true,
+			run: (*REPL).cmdNext},
+		{names: []string{"registers", "regs", "r"}, desc: "Show register values",
+			supported: func(c Capabilities) bool { return c.Registers }, program: true,
+			run: (*REPL).cmdRegisters},
+		{names: []string{"memory", "mem"}, usage: "<addr> [size]", desc: "Show memory contents",
+			supported: func(c Capabilities) bool { return c.ReadMemory }, program: true,
+			run: (*REPL).cmdMemory},
+		{names: []string{"x", "examine"}, usage: "/<n><fmt><unit> <expr>", desc: "Examine memory (fmt: x d u o t c s i, unit: b h w g; expr: 0x..., $rsp+8, &var, func)",
+			supported: func(c Capabilities) bool { return c.ReadMemory }, program: true, complete: (*REPL).completeVariables,
+			run: (*REPL).cmdExamine},
+		{names: []string{"stack", "bt"}, desc: "Show stack trace",
+			supported: func(c Capabilities) bool { return c.StackTrace }, program: true,
+			run: (*REPL).cmdStack},
+		{names: []string{"frame", "f"}, usage: "[n]", desc: "Select and show stack frame n (variables are resolved in the selected frame)",
+			supported: func(c Capabilities) bool { return c.StackTrace }, program: true,
+			run: (*REPL).cmdFrame},
+		{names: []string{"up"}, usage: "[n]", desc: "Select the frame n levels up (towards the caller)",
+			supported: func(c Capabilities) bool { return c.StackTrace }, program: true,
+			run: (*REPL).cmdUp},
+		{names: []string{"down"}, usage: "[n]", desc: "Select the frame n levels down (towards the callee)",
+			supported: func(c Capabilities) bool { return c.StackTrace }, program: true,
+			run: (*REPL).cmdDown},
+		{names: []string{"locals"}, desc: "Show local variables in scope in the selected frame",
+			supported: func(c Capabilities) bool { return c.StackTrace && c.ReadMemory }, program: true,
+			run: (*REPL).cmdLocals},
+		{names: []string{"args"}, desc: "Show arguments of the selected frame",
+			supported: func(c Capabilities) bool { return c.StackTrace && c.ReadMemory }, program: true,
+			run: (*REPL).cmdArgs},
+		{names: []string{"vars"}, usage: "[regex]", desc: "Show package-level variables whose name matches regex",
+			supported: func(c Capabilities) bool { return c.ReadMemory }, program: true,
+			run: (*REPL).cmdVars},
+		{names: []string{"breakpoints"}, desc: "List all breakpoints", program: true,
+			run: (*REPL).cmdBreakpoints},
+		{names: []string{"info"}, usage: "[breakpoints|inferiors]", desc: "List breakpoints, or the process and the processes held after fork",
+			program: true, run: (*REPL).cmdInfo},
+		{names: []string{"save-breakpoints"}, usage: "<file>", desc: "Save breakpoints (location, condition, commands) to a file",
+			supported: func(c Capabilities) bool { return c.Breakpoints }, program: true, complete: completePaths,
+			run: (*REPL).cmdSaveBreakpoints},
+		{names: []string{"load-breakpoints"}, usage: "<file>", desc: "Set breakpoints saved by save-breakpoints",
+			supported: func(c Capabilities) bool { return c.Breakpoints }, program: true, complete: completePaths,
+			run: (*REPL).cmdLoadBreakpoints},
+		{names: []string{"condition"}, usage: "<id> [cond]", desc: "Set or remove the condition of a breakpoint (e.g. condition 1 n == 3)",
+			supported: func(c Capabilities) bool { return c.Breakpoints }, program: true, complete: (*REPL).completeBreakpoints,
+			run: (*REPL).cmdCondition},
+		{names: []string{"commands"}, usage: "[id] ... end", desc: "Set commands to run when a breakpoint is hit",
+			supported: func(c Capabilities) bool { return c.Breakpoints }, program: true, complete: (*REPL).completeBreakpoints,
+			run: (*REPL).cmdCommands},
+		{names: []string{"print", "printvar"}, usage: "<var> [size]", desc: "Print a variable by its DWARF type, or its first size raw bytes",
+			supported: func(c Capabilities) bool { return c.ReadMemory }, program: true, complete: (*REPL).completeVariables,
+			run: (*REPL).cmdPrint},
+		{names: []string{"set", "setvar"}, usage: "<var> <value>", desc: "Assign an integer, bool, float or pointer to a variable, or change a setting (follow-fork-mode parent|child, detach-on-fork on|off)",
+			supported: func(c Capabilities) bool { return c.WriteMemory }, program: true, complete: (*REPL).completeVariables,
+			run: (*REPL).cmdSet},
+		{names: []string{"display"}, usage: "[/fmt] [expr]", desc: "Show an expression every time the program stops, e.g. display total, display/i $pc",
+			complete: (*REPL).completeVariables,
+			run:      (*REPL).cmdDisplay},
+		{names: []string{"undisplay"}, usage: "[id...]", desc: "Remove display expressions (all when no id is given)",
+			run: (*REPL).cmdUndisplay},
+		{names: []string{"call"}, usage: "<func>(<args>)", desc: "Call a function in the program, e.g. call main.fibonacci(10)",
+			supported: func(c Capabilities) bool { return c.Call }, program: true, complete: (*REPL).completeLocations,
+			run: (*REPL).cmdCall},
+		{names: []string{"checkpoint"}, desc: "Save a snapshot of the stopped process (forks it, like gdb's checkpoint)",
+			supported: func(c Capabilities) bool { return c.Checkpoint }, program: true,
+			run: (*REPL).cmdCheckpoint},
+		{names: []string{"checkpoints"}, desc: "List saved checkpoints",
+			supported: func(c Capabilities) bool { return c.Checkpoint }, program: true,
+			run: (*REPL).cmdCheckpoints},
+		{names: []string{"restart"}, usage: "[--rebuild] | <checkpoint>", desc: "Relaunch with the same arguments (optionally rebuilding first) and re-set breakpoints by location, or return to a checkpoint",
+			supported: func(c Capabilities) bool { return c.Launch }, program: true,
+			run: (*REPL).cmdRestart},
+		{names: []string{"record"}, usage: "[size|stop|info]", desc: "Record executed instructions so they can be undone (continue and step single-step the current thread)",
+			supported: func(c Capabilities) bool { return c.Record }, program: true,
+			run: (*REPL).cmdRecord},
+		{names: []string{"reverse-step", "rs"}, desc: "Undo the last recorded instruction",
+			supported: func(c Capabilities) bool { return c.Record }, program: true, repeat: true,
+			run: (*REPL).cmdReverseStep},
+		{names: []string{"reverse-continue", "rc"}, desc: "Run backwards to the previous breakpoint within the recorded history",
+			supported: func(c Capabilities) bool { return c.Record }, program: true,
+			run: (*REPL).cmdReverseContinue},
+		{names: []string{"handle"}, usage: "[signal [actions]]", desc: "Show or set signal handling (stop/nostop, print/noprint, pass/nopass)",
+			complete: completeSignals,
+			run:      (*REPL).cmdHandle},
+		{names: []string{"detach"}, usage: "[inferior <pid>]", desc: "Detach from process, or let a process held after fork run",
+			supported: func(c Capabilities) bool { return c.Detach }, program: true,
+			run: (*REPL).cmdDetach},
+		{names: []string{"kill"}, desc: "Kill the process (restart relaunches it with the same arguments and breakpoints)",
+			supported: func(c Capabilities) bool { return c.Kill }, program: true,
+			run: (*REPL).cmdKill},
+		{names: []string{"source"}, usage: "<file>", desc: "Execute debugger commands from a file",
+			complete: completePaths,
+			run:      (*REPL).cmdSource},
+		{names: []string{"alias"}, usage: "[name [=] command...]", desc: "Show or define a command alias",
+			complete: (*REPL).completeCommands,
+			run:      (*REPL).cmdAlias},
+		{names: []string{"define"}, usage: "<name> ... end", desc: "Define a command macro ($1..$9, $*, $# expand to its arguments)",
+			run: (*REPL).cmdDefine},
+		{names: []string{"unalias"}, usage: "<name>", desc: "Remove a user-defined alias or macro",
+			complete: (*REPL).completeUserCommands,
+			run:      (*REPL).cmdUnalias},
+		{names: []string{"quit", "q", "exit"}, desc: "Exit debugger",
+			run: (*REPL).cmdQuit},
+	} {
+		builtinCommands.register(info)
+	}
+}
+
+// lookupCommand 按名字或别名查找内置命令
+func lookupCommand(name string) *commandInfo {
+	return builtinCommands.lookup(name)
+}
+
+// checkSupported 后端不支持该命令时返回 ErrUnsupported
+func (r *REPL) checkSupported(info *commandInfo) error {
+	if info.supported == nil || r.Debugger == nil {
+		return nil
+	}
+	if !info.supported(r.Debugger.Capabilities()) {
+		return unsupported(info.names[0])
+	}
+	return nil
+}
+
+// executeCommand 执行一条命令，用户别名和宏优先于内置命令展开
+func (r *REPL) executeCommand(command string, args []string) error {
+	if expansion, ok := r.aliases[command]; ok {
+		return r.runAlias(command, expansion, args)
+	}
+	if body, ok := r.macros[command]; ok {
+		return r.runMacro(command, body, args)
+	}
+
+	info := lookupCommand(command)
+	if info == nil {
+		return fmt.Errorf("%w: %s", errUnknownCommand, command)
+	}
+	if err := r.checkSupported(info); err != nil {
+		return err
+	}
+	if info.program && r.Debugger == nil {
+		return errNoProgram
+	}
+	return info.run(r, args)
+}
+
+func (r *REPL) cmdHelp(args []string) error {
+	r.output(r.help())
+	return nil
+}
+
+func (r *REPL) cmdLaunch(args []string) error {
+	opts, args, err := parseLaunchArgs(args)
+	if err != nil {
+		return err
+	}
+	executable := args[0]
+	programArgs := args[1:]
+
+	debugger, err := NewDebugger(executable)
+	if err != nil {
+		return err
+	}
+	debugger.LaunchOptions = opts
+	r.setDebugger(debugger)
+	if err := r.Debugger.Launch(programArgs); err != nil {
+		return err
+	}
+	r.onStop()
+	r.autoLoadBreakpoints()
+	return nil
+}
+
+const launchUsage = "usage: launch [--stdin file] [--stdout file] [--stderr file] [--tty device] [--env K=V]... [--cwd dir] <program> [args...]"
+
+// parseLaunchArgs 解析程序名之前的选项，返回选项和程序名及其参数
+func parseLaunchArgs(args []string) (LaunchOptions, []string, error) {
+	var opts LaunchOptions
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		flag := args[0]
+		if flag == "--" {
+			args = args[1:]
+			break
+		}
+		if len(args) < 2 {
+			return opts, nil, fmt.Errorf(launchUsage)
+		}
+		value := args[1]
+		switch flag {
+		case "--stdin":
+			opts.Stdin = value
+		case "--stdout":
+			opts.Stdout = value
+		case "--stderr":
+			opts.Stderr = value
+		case "--tty":
+			opts.TTY = value
+		case "--env":
+			if !strings.Contains(value, "=") {
+				return opts, nil, fmt.Errorf("invalid environment variable %q, expected K=V", value)
+			}
+			opts.Env = append(opts.Env, value)
+		case "--cwd":
+			opts.Dir = value
+		default:
+			return opts, nil, fmt.Errorf("unknown launch option %s\n%s", flag, launchUsage)
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		return opts, nil, fmt.Errorf(launchUsage)
+	}
+	return opts, args, nil
+}
+
+// setDebugger 切换到新创建的调试器，信号处理策略和 fork 的处理方式沿用之前的设置，
+// 程序的输出以程序名为前缀显示
+func (r *REPL) setDebugger(debugger *Debugger) {
+	prefix := "[" + filepath.Base(debugger.Executable) + "] "
+	debugger.Signals = r.signals
+	debugger.Fork = r.fork
+	debugger.OnEvent = r.onEvent
+	debugger.OnOutput = func(data []byte) { r.onOutput(prefix, data) }
+	r.Debugger = debugger
+}
+
+// cmdInput input <text> 向程序的终端写入一行输入；input --eof 发送文件结束符
+func (r *REPL) cmdInput(args []string) error {
+	data := strings.Join(args, " ") + "\n"
+	if len(args) == 1 && args[0] == "--eof" {
+		data = "\x04"
+	}
+	return r.Debugger.WriteInput(data)
+}
+
+func (r *REPL) cmdContinue(args []string) error {
+	return r.resume(r.Debugger.Continue)
+}
+
+// stopResult 返回程序停下后的位置
+func (r *REPL) stopResult() stopResult {
+	res := stopResult{Running: r.Debugger != nil && r.Debugger.IsRunning}
+	if !res.Running {
+		return res
+	}
+	if bp := r.Debugger.HitBreakpoint(); bp != nil {
+		res.Breakpoint = bp.ID
+	}
+	if len(r.frames) > 0 {
+		frame := r.frames[0]
+		res.Frame = &frame
+	}
+	res.Displays = r.evalDisplays()
+	return res
+}
+
+func (r *REPL) cmdBreak(args []string) error {
+	if len(args) == 0 || len(args) > 1 && (args[1] != "if" || len(args) == 2) {
+		return fmt.Errorf("usage: break <address|function|file:line> [if <condition>]")
+	}
+
+	target := args[0]
+	address, err := r.Debugger.ResolveLocation(target)
+	if err != nil {
+		return err
+	}
+	var cond string
+	if len(args) > 2 {
+		cond = strings.Join(args[2:], " ")
+		if _, err := parseCondition(cond); err != nil {
+			return err
+		}
+	}
+	// 同一位置已有断点（例如自动加载的）时沿用它，使重复执行的脚本不会失败
+	if bp, ok := r.Debugger.Breakpoints[address]; ok && !bp.internal {
+		if cond != "" {
+			bp.Condition = cond
+		}
+		r.output(messageResult{Message: fmt.Sprintf("Breakpoint %d already set at 0x%x", bp.ID, address)})
+		return nil
+	}
+	if err := r.Debugger.SetBreakpoint(address); err != nil {
+		return err
+	}
+	bp := r.Debugger.Breakpoints[address]
+	bp.Spec, bp.Condition = target, cond
+	return nil
+}
+
+func (r *REPL) cmdDelete(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: delete <id|address>")
+	}
+
+	if id, err := strconv.Atoi(args[0]); err == nil && r.Debugger.CatchpointByID(id) != nil {
+		return r.Debugger.DeleteCatchpoint(id)
+	}
+	bp, err := r.findBreakpoint(args[0])
+	if err != nil {
+		return err
+	}
+
+	return r.Debugger.RemoveBreakpoint(bp.Address)
+}
+
+func (r *REPL) cmdStep(args []string) error {
+	return r.resume(r.Debugger.Step)
+}
+
+func (r *REPL) cmdNext(args []string) error {
+	return r.resume(r.Debugger.Next)
+}
+
+func (r *REPL) cmdRegisters(args []string) error {
+	registers, err := r.Debugger.registerList()
+	if err != nil {
+		return err
+	}
+	r.output(registersResult{Registers: registers})
+	return nil
+}
+
+func (r *REPL) cmdMemory(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: memory <address> [size]")
+	}
+
+	addr, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid address: %s", args[0])
+	}
+
+	size := 16 // 默认读取16字节
+	if len(args) > 1 {
+		s, err := strconv.Atoi(args[1])
+		if err != nil || s <= 0 {
+			return fmt.Errorf("usage: memory <address> [size]: size must be a positive number")
+		}
+		if s > maxExamineBytes {
+			return fmt.Errorf("size %d too large: memory reads at most %d bytes at a time", s, maxExamineBytes)
+		}
+		size = s
+	}
+
+	data, err := r.Debugger.ReadMemory(addr, size)
+	if err != nil {
+		return err
+	}
+
+	r.output(newMemoryResult(addr, data))
+	return nil
+}
+
+func (r *REPL) cmdStack(args []string) error {
+	frames, err := r.Debugger.Stack()
+	if err != nil {
+		return err
+	}
+	r.output(stackResult{Frames: frames})
+	return nil
+}
+
+// cmdFrame frame [n] 选中第 n 帧，不带参数时显示当前选中的帧
+func (r *REPL) cmdFrame(args []string) error {
+	if len(args) == 0 {
+		return r.selectFrame(r.frameIdx)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid frame number: %s", args[0])
+	}
+	return r.selectFrame(n)
+}
+
+// cmdUp up [n] 向调用者方向移动 n 帧
+func (r *REPL) cmdUp(args []string) error {
+	n, err := frameCount(args)
+	if err != nil {
+		return err
+	}
+	if r.frameIdx+1 >= len(r.frames) {
+		return fmt.Errorf("initial frame selected; you cannot go up")
+	}
+	return r.selectFrame(min(r.frameIdx+n, len(r.frames)-1))
+}
+
+// cmdDown down [n] 向被调用者方向移动 n 帧
+func (r *REPL) cmdDown(args []string) error {
+	n, err := frameCount(args)
+	if err != nil {
+		return err
+	}
+	if r.frameIdx == 0 {
+		return fmt.Errorf("bottom (innermost) frame selected; you cannot go down")
+	}
+	return r.selectFrame(max(r.frameIdx-n, 0))
+}
+
+// frameCount 解析 up/down 的可选步数，默认为 1
+func frameCount(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid frame count: %s", args[0])
+	}
+	return n, nil
+}
+
+// selectFrame 选中第 n 帧，之后的 print、set、x、display 等在该帧中解析变量
+func (r *REPL) selectFrame(n int) error {
+	if len(r.frames) == 0 {
+		return fmt.Errorf("no stack")
+	}
+	if n >= len(r.frames) {
+		return fmt.Errorf("no frame at level %d (stack has %d frames)", n, len(r.frames))
+	}
+	r.frameIdx = n
+	r.output(frameResult{Index: n, Frame: r.frames[n]})
+	return nil
+}
+
+func (r *REPL) cmdBreakpoints(args []string) error {
+	res := breakpointsResult{Breakpoints: []breakpointInfo{}, Catchpoints: r.Debugger.catchpointInfos()}
+	for _, bp := range r.Debugger.SortedBreakpoints() {
+		res.Breakpoints = append(res.Breakpoints, breakpointInfo{
+			ID:        bp.ID,
+			Address:   bp.Address,
+			Enabled:   bp.Enabled,
+			HitCount:  bp.HitCount,
+			Location:  r.Debugger.frameAt(bp.Address),
+			Spec:      bp.Spec,
+			Condition: bp.Condition,
+			Commands:  bp.Commands,
+		})
+	}
+	r.output(res)
+	return nil
+}
+
+func (r *REPL) cmdCommands(args []string) error {
+	var bp *Breakpoint
+	if len(args) == 0 {
+		// 默认为最后设置的断点
+		bp = r.Debugger.BreakpointByID(r.Debugger.nextBreakpointID)
+		if bp == nil {
+			return fmt.Errorf("no breakpoints set")
+		}
+	} else {
+		var err error
+		if bp, err = r.findBreakpoint(args[0]); err != nil {
+			return err
+		}
+	}
+	if r.input == nil {
+		return fmt.Errorf("commands requires interactive or script input")
+	}
+	if r.interactive() {
+		fmt.Fprintf(r.out, "Type commands for breakpoint %d, one per line.\nEnd with a line saying just \"end\".\n", bp.ID)
+	}
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	bp.Commands = body
+	return nil
+}
+
+func (r *REPL) cmdDetach(args []string) error {
+	if len(args) > 0 {
+		pid, err := 0, fmt.Errorf("usage: detach [inferior <pid>]")
+		if len(args) == 2 && args[0] == "inferior" {
+			pid, err = strconv.Atoi(args[1])
+		}
+		if err != nil {
+			return fmt.Errorf("usage: detach [inferior <pid>]")
+		}
+		if err := r.Debugger.DetachInferior(pid); err != nil {
+			return err
+		}
+		r.output(messageResult{Message: fmt.Sprintf("Detached from process %d", pid)})
+		return nil
+	}
+	if err := r.Debugger.Detach(); err != nil {
+		return err
+	}
+
+	r.output(messageResult{Message: "Detached from process"})
+	r.Debugger = nil
+	return nil
+}
+
+// cmdKill 结束进程，保留程序、参数和断点，之后可以用 restart 重新启动
+func (r *REPL) cmdKill(args []string) error {
+	return r.Debugger.Kill()
+}
+
+func (r *REPL) cmdHandle(args []string) error {
+	if len(args) == 0 {
+		r.output(newSignalsResult(r.signals, nil))
+		return nil
+	}
+	sigs, err := r.signals.Handle(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	r.output(newSignalsResult(r.signals, sigs))
+	return nil
+}
+
+func (r *REPL) cmdSource(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: source <file>")
+	}
+	return r.Source(args[0])
+}
+
+func (r *REPL) cmdQuit(args []string) error {
+	if r.Debugger != nil && r.Debugger.IsRunning {
+		r.Debugger.Detach()
+	}
+	r.output(messageResult{Message: "Goodbye!"})
+	r.quit = true
+	return nil
+}
+
+func (r *REPL) cmdPrint(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: print <varname> [size]")
+	}
+	varname := args[0]
+	if len(args) == 1 {
+		v, err := r.Debugger.ReadVariable(varname, r.frame())
+		if err != nil {
+			return err
+		}
+		r.output(variableResult{Variable: v})
+		return nil
+	}
+	// 指定大小时显示原始字节
+	size, err := strconv.Atoi(args[1])
+	if err != nil || size <= 0 {
+		return fmt.Errorf("invalid size: %s", args[1])
+	}
+	v, data, err := r.Debugger.ReadVariableBytes(varname, r.frame(), size)
+	if err != nil {
+		return err
+	}
+	r.output(variableResult{Variable: v, Hex: hex.EncodeToString(data), data: data})
+	return nil
+}
+
+func (r *REPL) cmdSet(args []string) error {
+	if len(args) > 0 {
+		if ok, err := r.setOption(args); ok {
+			return err
+		}
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("usage: set <varname> <value> | set follow-fork-mode parent|child | set detach-on-fork on|off")
+	}
+	v, err := r.Debugger.SetVariable(args[0], r.frame(), args[1])
+	if err != nil {
+		return err
+	}
+	r.output(assignResult{Variable: v})
+	return nil
+}
+
+// help 列出内置命令及其在当前后端上是否可用，以及用户定义的别名和宏
+func (r *REPL) help() helpResult {
+	var caps Capabilities
+	if r.Debugger != nil {
+		caps = r.Debugger.Capabilities()
+	}
+
+	var res helpResult
+	for _, info := range builtinCommands.list {
+		res.Commands = append(res.Commands, helpEntry{
+			Names:     info.names,
+			Usage:     info.usage,
+			Desc:      info.desc,
+			Supported: info.supported == nil || r.Debugger == nil || info.supported(caps),
+		})
+	}
+	for _, name := range sortedKeys(r.aliases) {
+		res.User = append(res.User, userCommandEntry{Name: name, Alias: r.aliases[name]})
+	}
+	for _, name := range sortedKeys(r.macros) {
+		res.User = append(res.User, userCommandEntry{Name: name, Macro: r.macros[name]})
+	}
+	return res
+}
+
+// sortedKeys 按字典序返回 map 的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
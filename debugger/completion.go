@@ -0,0 +1,144 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// complete 是行编辑器的补全回调：第一个词补全命令名，之后交给命令自己的参数补全
+func (r *REPL) complete(line string, pos int) (string, []string, string) {
+	head, tail := line[:pos], line[pos:]
+	start := strings.LastIndexAny(head, " \t") + 1
+	word := head[start:]
+	head = head[:start]
+
+	var candidates []string
+	fields := strings.Fields(head)
+	if len(fields) == 0 {
+		candidates = r.completeCommands(word)
+	} else if info := r.resolveCommand(fields[0]); info != nil && info.complete != nil {
+		candidates = info.complete(r, word)
+	}
+
+	return head, filterPrefix(candidates, word), tail
+}
+
+// completeCommands 补全内置命令名以及用户定义的别名和宏
+func (r *REPL) completeCommands(word string) []string {
+	return append(builtinCommands.names(), r.completeUserCommands(word)...)
+}
+
+// completeUserCommands 补全用户定义的别名和宏
+func (r *REPL) completeUserCommands(word string) []string {
+	return filterPrefix(append(sortedKeys(r.aliases), sortedKeys(r.macros)...), word)
+}
+
+// filterPrefix 保留以 prefix 开头的候选并去重，保持候选原有的顺序
+func filterPrefix(candidates []string, prefix string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) && !seen[c] {
+			seen[c] = true
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// completeLocations 补全符号表中的函数名和 file: 形式的源文件
+func (r *REPL) completeLocations(word string) []string {
+	d := r.Debugger
+	if d == nil {
+		return nil
+	}
+	var candidates []string
+	for name, addr := range d.Symbols {
+		if !strings.HasPrefix(name, word) {
+			continue
+		}
+		// 符号表中还有变量等数据符号，有调试信息时只保留地址落在同名函数中的
+		if d.DwarfData != nil {
+			if fn, err := d.FunctionAt(addr); err != nil || fn != name {
+				continue
+			}
+		}
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+	if d.index != nil && (strings.ContainsAny(word, "/.:") || word == "") {
+		for _, file := range d.index.sourceFiles() {
+			candidates = append(candidates, file+":", filepath.Base(file)+":")
+		}
+	}
+	return candidates
+}
+
+// completeBreakpoints 补全已设置的断点编号
+func (r *REPL) completeBreakpoints(word string) []string {
+	if r.Debugger == nil {
+		return nil
+	}
+	var candidates []string
+	for _, bp := range r.Debugger.SortedBreakpoints() {
+		candidates = append(candidates, strconv.Itoa(bp.ID))
+	}
+	for _, c := range r.Debugger.Catchpoints {
+		candidates = append(candidates, strconv.Itoa(c.ID))
+	}
+	return candidates
+}
+
+// completeVariables 补全当前函数的局部变量、参数以及包级变量
+func (r *REPL) completeVariables(word string) []string {
+	if r.Debugger == nil || r.Debugger.index == nil {
+		return nil
+	}
+	idx := r.Debugger.index
+	var globals []string
+	for name := range idx.globals {
+		globals = append(globals, name)
+	}
+	sort.Strings(globals)
+	// 局部变量优先
+	var candidates []string
+	if frame := r.frame(); frame != nil {
+		if fn := idx.funcByPC(frame.lookupPC()); fn != nil {
+			candidates = append(candidates, idx.localNames(fn, frame.lookupPC())...)
+		}
+	}
+	return append(candidates, globals...)
+}
+
+// completeSignals 补全信号名和动作
+func completeSignals(r *REPL, word string) []string {
+	candidates := []string{"all", "stop", "nostop", "print", "noprint", "pass", "nopass"}
+	for _, info := range knownSignals {
+		candidates = append(candidates, info.Name)
+	}
+	return candidates
+}
+
+// completeCatch 补全 catch 的事件类型和系统调用名
+func completeCatch(r *REPL, word string) []string {
+	candidates := []string{string(CatchSyscall), string(CatchPanic)}
+	for _, name := range syscallNames {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates[2:])
+	return candidates
+}
+
+// completePaths 补全文件系统路径
+func completePaths(r *REPL, word string) []string {
+	matches, _ := filepath.Glob(word + "*")
+	for i, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.IsDir() {
+			matches[i] = m + string(filepath.Separator)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,196 @@
+package debugger
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// conditionOps 断点条件支持的比较运算符，两个字符的放在前面优先匹配
+var conditionOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// condition 断点条件：<操作数> [<运算符> <操作数>]，操作数是变量名或字面量（整数、浮点数、字符串、true/false/nil）。
+// 只有一个操作数时，值为 true 或非零数字即成立。
+type condition struct {
+	lhs, op, rhs string
+}
+
+// parseCondition 解析断点条件
+func parseCondition(expr string) (condition, error) {
+	expr = strings.TrimSpace(expr)
+	inQuote := false
+	for i := 0; i < len(expr); i++ {
+		if expr[i] == '"' {
+			inQuote = !inQuote
+			continue
+		}
+		if inQuote {
+			continue
+		}
+		for _, op := range conditionOps {
+			if strings.HasPrefix(expr[i:], op) {
+				c := condition{lhs: strings.TrimSpace(expr[:i]), op: op, rhs: strings.TrimSpace(expr[i+len(op):])}
+				if !validOperand(c.lhs) || !validOperand(c.rhs) {
+					return condition{}, fmt.Errorf("invalid condition: %s", expr)
+				}
+				return c, nil
+			}
+		}
+	}
+	if !validOperand(expr) {
+		return condition{}, fmt.Errorf("invalid condition: %s", expr)
+	}
+	return condition{lhs: expr}, nil
+}
+
+// validOperand 操作数是一个不含空白的名字或字面量，或者一个带引号的字符串
+func validOperand(s string) bool {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return true
+	}
+	return s != "" && !strings.ContainsAny(s, " \t\"")
+}
+
+// SetCondition 设置断点的条件，expr 为空时删除条件
+func (d *Debugger) SetCondition(bp *Breakpoint, expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		bp.Condition = ""
+		return nil
+	}
+	if _, err := parseCondition(expr); err != nil {
+		return err
+	}
+	bp.Condition = strings.TrimSpace(expr)
+	return nil
+}
+
+// conditionHolds 在当前线程的最内层帧中求值断点条件，求值出错时视为成立，让用户看到停下的位置
+func (d *Debugger) conditionHolds(bp *Breakpoint) bool {
+	if bp.Condition == "" {
+		return true
+	}
+	c, err := parseCondition(bp.Condition)
+	if err != nil {
+		return true
+	}
+	frames, err := d.stacktrace(1)
+	if err != nil || len(frames) == 0 {
+		return true
+	}
+	frame := &frames[0]
+	lhs, err := d.conditionOperand(c.lhs, frame)
+	if err != nil {
+		return true
+	}
+	if c.op == "" {
+		if n, err := strconv.ParseFloat(lhs, 64); err == nil {
+			return n != 0
+		}
+		return lhs == "true"
+	}
+	rhs, err := d.conditionOperand(c.rhs, frame)
+	if err != nil {
+		return true
+	}
+	holds, err := compareValues(lhs, c.op, rhs)
+	if err != nil {
+		return true
+	}
+	return holds
+}
+
+// conditionOperand 字面量原样返回，变量名按类型格式化后返回，与 print 的输出一致
+func (d *Debugger) conditionOperand(s string, frame *Frame) (string, error) {
+	switch {
+	case s == "true", s == "false", s == "nil", strings.HasPrefix(s, `"`):
+		return s, nil
+	case s[0] == '-' || s[0] >= '0' && s[0] <= '9':
+		if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return strconv.FormatInt(n, 10), nil
+		}
+		if n, err := strconv.ParseUint(s, 0, 64); err == nil {
+			return strconv.FormatUint(n, 10), nil
+		}
+		return s, nil
+	}
+	v, err := d.ReadVariable(s, frame)
+	if err != nil {
+		return "", err
+	}
+	return v.Value, nil
+}
+
+// compareValues 两边都是数字时按数值比较，否则只能比较是否相等
+func compareValues(lhs, op, rhs string) (bool, error) {
+	if c, ok := compareNumbers(lhs, rhs); ok {
+		switch op {
+		case "==":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		case ">=":
+			return c >= 0, nil
+		}
+	}
+	switch op {
+	case "==":
+		return lhs == rhs, nil
+	case "!=":
+		return lhs != rhs, nil
+	}
+	return false, fmt.Errorf("cannot compare %s %s %s", lhs, op, rhs)
+}
+
+// compareNumbers 比较两个数字，返回 -1、0 或 1。整数按 int64/uint64 精确比较，
+// 只有其中一边是浮点数时才按 float64 比较
+func compareNumbers(a, b string) (int, bool) {
+	x, errX := strconv.ParseInt(a, 10, 64)
+	y, errY := strconv.ParseInt(b, 10, 64)
+	if errX == nil && errY == nil {
+		return cmp.Compare(x, y), true
+	}
+	// 超出 int64 的正整数
+	ux, errUX := strconv.ParseUint(a, 10, 64)
+	uy, errUY := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errUX == nil && errUY == nil:
+		return cmp.Compare(ux, uy), true
+	case errX == nil && errUY == nil:
+		return -1, true
+	case errUX == nil && errY == nil:
+		return 1, true
+	}
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return 0, false
+	}
+	return cmp.Compare(fa, fb), true
+}
+
+// cmdCondition condition <id> [expr] 设置或删除断点的条件
+func (r *REPL) cmdCondition(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: condition <id> [expression]")
+	}
+	bp, err := r.findBreakpoint(args[0])
+	if err != nil {
+		return err
+	}
+	if err := r.Debugger.SetCondition(bp, strings.Join(args[1:], " ")); err != nil {
+		return err
+	}
+	if bp.Condition == "" {
+		r.output(messageResult{Message: fmt.Sprintf("Breakpoint %d now unconditional.", bp.ID)})
+	} else {
+		r.output(messageResult{Message: fmt.Sprintf("Breakpoint %d condition: %s", bp.ID, bp.Condition)})
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package debugger
+
+import "testing"
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    condition
+		wantErr bool
+	}{
+		{expr: "i >= 3", want: condition{lhs: "i", op: ">=", rhs: "3"}},
+		{expr: "i<3", want: condition{lhs: "i", op: "<", rhs: "3"}},
+		{expr: `name == "a <= b"`, want: condition{lhs: "name", op: "==", rhs: `"a <= b"`}},
+		{expr: "  done  ", want: condition{lhs: "done"}},
+		{expr: "n != -1", want: condition{lhs: "n", op: "!=", rhs: "-1"}},
+		{expr: "== 3", wantErr: true},
+		{expr: "i >", wantErr: true},
+		{expr: "a b == 3", wantErr: true},
+		{expr: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCondition(tt.expr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCondition(%q) = %+v, want error", tt.expr, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseCondition(%q) = %+v, %v, want %+v", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		lhs, op, rhs string
+		want         bool
+		wantErr      bool
+	}{
+		{lhs: "3", op: ">=", rhs: "3", want: true},
+		{lhs: "-5", op: "<", rhs: "2", want: true},
+		{lhs: "2.5", op: ">", rhs: "2", want: true},
+		{lhs: "10", op: "==", rhs: "10.0", want: true},
+		// 超过 2^53 的整数按 float64 比较会相等
+		{lhs: "9007199254740993", op: "==", rhs: "9007199254740992", want: false},
+		{lhs: "9007199254740993", op: ">", rhs: "9007199254740992", want: true},
+		// 超出 int64 的 uint64
+		{lhs: "18446744073709551615", op: ">", rhs: "18446744073709551614", want: true},
+		{lhs: "18446744073709551615", op: ">", rhs: "-1", want: true},
+		{lhs: "-1", op: "<", rhs: "9223372036854775808", want: true},
+		{lhs: `"foo"`, op: "==", rhs: `"foo"`, want: true},
+		{lhs: `"foo"`, op: "!=", rhs: `"bar"`, want: true},
+		{lhs: `"foo"`, op: "<", rhs: `"bar"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := compareValues(tt.lhs, tt.op, tt.rhs)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("compareValues(%s %s %s) = %v, want error", tt.lhs, tt.op, tt.rhs, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("compareValues(%s %s %s) = %v, %v, want %v", tt.lhs, tt.op, tt.rhs, got, err, tt.want)
+		}
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"debug/pe"
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 )
 
@@ -16,14 +19,73 @@ type Debugger struct {
 	Executable  string
 	Symbols     map[string]uint64
 	Breakpoints map[uint64]*Breakpoint
+	Catchpoints []*Catchpoint
 	DwarfData   *dwarf.Data
 	IsRunning   bool
+	ProgramArgs []string // 启动程序时的参数，restart 时沿用
+	Signals     *SignalTable
+	Fork        *ForkSettings
+	// LaunchOptions 在 Launch 之前设置程序的标准输入输出、环境变量和工作目录
+	LaunchOptions LaunchOptions
+	// OnEvent 接收启动、断点命中、收到信号、退出等事件，为 nil 时打印到标准输出
+	OnEvent func(Event)
+	// OnOutput 接收程序写到自动分配的伪终端上的输出，在单独的 goroutine 中调用，为 nil 时写到标准输出
+	OnOutput func([]byte)
+
+	arch     arch
+	index    *symbolIndex
+	frames   *frameTable
+	sections debugSections
+	tid      int // 当前线程，寄存器和单步都作用于它
+	threads  map[int]*thread
+
+	interrupted atomic.Bool  // Interrupt 已请求暂停，等待主线程的 SIGSTOP
+	stepping    atomic.Int32 // 记录模式下正在单步的线程，Interrupt 把 SIGSTOP 发给它
+
+	nextBreakpointID int
+	hit              *Breakpoint // 最近一次停止时命中的断点
+	stop             *StopReason // 最近一次 Continue、Step 等操作结束时的停止原因
+	stopSeq          int         // 每次恢复执行后加一，用于判断程序是否运行过
+	calling          bool        // 正在注入函数调用，debugCallV2 的 int3 不作为停止事件报告
+
+	call *pendingCall // 在被调用的函数中停下、continue 时完成的调用
+
+	record           *recordLog // 记录模式开启时非 nil，continue、step 逐条指令记录执行前的状态
+	checkpoints      []*Checkpoint
+	nextCheckpointID int
+
+	pty        *os.File      // 自动分配的伪终端的主设备，程序的标准输入输出连接到从设备
+	outputDone chan struct{} // 伪终端的输出转发结束时关闭
+
+	forkStops    map[int]bool  // 先于父进程的 fork 事件收到初始暂停的子进程
+	pendingForks []pendingFork // 尚未处理的 fork 事件
+	held         []heldProcess // detach-on-fork 关闭时保持暂停的进程
+	vforkChild   int           // 保持暂停的 vfork 子进程，它 exec 或退出之前父进程无法继续运行
+	vforkParent  *heldProcess  // 跟随 vfork 的子进程后，等子进程 exec 或退出再放开的父进程
+	vforkLifted  bool          // vfork 的子进程与父进程共享内存，在它 exec 之前断点被临时移除
+}
+
+// thread 被跟踪的线程
+type thread struct {
+	tid        int
+	stopped    bool
+	started    bool           // 已消费新线程的初始 SIGSTOP
+	expectStop bool           // 已发送 SIGSTOP 但尚未收到
+	pendingSig syscall.Signal // 恢复运行时注入的信号
+	syscall    *syscallCall   // 正在执行的系统调用，在进入时的停止处记录
 }
 
 type Breakpoint struct {
-	Address      uint64
-	OriginalByte byte
-	Enabled      bool
+	ID        int
+	Address   uint64
+	Original  []byte // 被断点指令覆盖的原始字节
+	Enabled   bool
+	HitCount  int
+	Commands  []string // 命中时由 REPL 自动执行的命令
+	Spec      string   // 设置时的位置（函数名、file:line 或地址），重新启动后据此重新解析
+	Condition string   // 条件不成立时命中断点不停下，见 parseCondition
+
+	internal bool // 调试器自己使用的临时断点（如 next 的返回地址），不计数也不报告
 }
 
 func NewDebugger(executable string) (*Debugger, error) {
@@ -31,7 +93,12 @@ func NewDebugger(executable string) (*Debugger, error) {
 		Executable:  executable,
 		Symbols:     make(map[string]uint64),
 		Breakpoints: make(map[uint64]*Breakpoint),
+		Catchpoints: []*Catchpoint{defaultPanicCatchpoint()},
 		IsRunning:   false,
+		Signals:     NewSignalTable(),
+		Fork:        NewForkSettings(),
+		forkStops:   make(map[int]bool),
+		arch:        nativeArch(),
 	}
 
 	// parse executable if provided
@@ -80,384 +147,386 @@ func (d *Debugger) loadSymbols() error {
 				d.Symbols[symbol.Name] = symbol.Value
 			}
 		}
+
+		// 位置列表用于查找参数等位置随 pc 变化的变量
+		for name, dst := range map[string]*[]byte{
+			".debug_loclists": &d.sections.loclists,
+			".debug_loc":      &d.sections.loc,
+			".debug_addr":     &d.sections.addr,
+		} {
+			if section := elfFile.Section(name); section != nil {
+				if *dst, err = section.Data(); err != nil {
+					return fmt.Errorf("failed to read %s: %v", name, err)
+				}
+			}
+		}
+
+		// 解析 .debug_frame 用于栈回溯
+		if section := elfFile.Section(".debug_frame"); section != nil {
+			data, err := section.Data()
+			if err != nil {
+				return fmt.Errorf("failed to read .debug_frame: %v", err)
+			}
+			ptrSize := 8
+			if elfFile.Class == elf.ELFCLASS32 {
+				ptrSize = 4
+			}
+			if d.frames, err = parseFrameTable(data, elfFile.ByteOrder, ptrSize); err != nil {
+				return fmt.Errorf("failed to parse .debug_frame: %v", err)
+			}
+		}
+	}
+
+	index, err := buildSymbolIndex(dwarfData)
+	if err != nil {
+		return fmt.Errorf("failed to index DWARF data: %v", err)
 	}
 
 	d.DwarfData = dwarfData
+	d.index = index
 	return nil
 }
 
 // Launch
 func (d *Debugger) Launch(args []string) error {
-	if runtime.GOOS == "linux" && runtime.GOARCH == "amd64" {
-		cmd := exec.Command(d.Executable, args...)
-		cmd.SysProcAttr = &syscall.SysProcAttr{Ptrace: true}
-		if err := cmd.Start(); err != nil {
-			return fmt.Errorf("failed to start process: %v", err)
-		}
-		d.Process = cmd.Process
-		d.IsRunning = true
-		// 等待子进程在 execve 处暂停
-		var ws syscall.WaitStatus
-		_, err := syscall.Wait4(d.Process.Pid, &ws, 0, nil)
-		if err != nil {
-			return fmt.Errorf("wait4 failed: %v", err)
-		}
-		fmt.Printf("Process started with PID: %d\n", d.Process.Pid)
-		return nil
-	} else {
-		// 其他平台维持原有模拟
-		cmd := exec.Command(d.Executable, args...)
-		if err := cmd.Start(); err != nil {
-			return fmt.Errorf("failed to start process: %v", err)
-		}
-		d.Process = cmd.Process
-		d.IsRunning = true
-		fmt.Printf("Process started with PID: %d\n", d.Process.Pid)
-		return nil
+	if err := d.launch(args); err != nil {
+		return err
 	}
+	d.ProgramArgs = args
+	d.emit(Event{Kind: EventStarted, PID: d.Process.Pid})
+	if d.Capabilities().Breakpoints {
+		return d.installCatchpoints()
+	}
+	return nil
 }
 
-// Continue
-func (d *Debugger) Continue() error {
+// Continue 继续运行，直到命中断点或捕获点、收到需要暂停的信号或进程结束，返回停下的原因
+func (d *Debugger) Continue() (StopReason, error) {
 	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
+		return StopReason{}, errNotRunning
 	}
-	if runtime.GOOS == "linux" && runtime.GOARCH == "amd64" {
-		if err := syscall.PtraceCont(d.Process.Pid, 0); err != nil {
-			return fmt.Errorf("ptrace cont failed: %v", err)
-		}
-		for {
-			var ws syscall.WaitStatus
-			_, err := syscall.Wait4(d.Process.Pid, &ws, 0, nil)
-			if err != nil {
-				return fmt.Errorf("wait4 failed: %v", err)
-			}
-			if ws.Exited() {
-				d.IsRunning = false
-				fmt.Println("Process exited")
-				return nil
-			}
-			if ws.Stopped() && ws.StopSignal() == syscall.SIGTRAP {
-				// 命中断点
-				rip, err := getRegRIP(d.Process.Pid)
-				if err != nil {
-					return fmt.Errorf("get rip failed: %v", err)
-				}
-				bpAddr := rip - 1
-				bp, ok := d.Breakpoints[bpAddr]
-				if ok && bp.Enabled {
-					// 恢复原字节
-					orig := []byte{bp.OriginalByte}
-					_, err := syscall.PtracePokeData(d.Process.Pid, uintptr(bpAddr), orig)
-					if err != nil {
-						return fmt.Errorf("restore original byte failed: %v", err)
-					}
-					// rip 回退
-					if err := setRegRIP(d.Process.Pid, bpAddr); err != nil {
-						return fmt.Errorf("set rip failed: %v", err)
-					}
-					fmt.Printf("Hit breakpoint at 0x%x\n", bpAddr)
-					return nil
-				}
-				fmt.Println("Stopped (SIGTRAP)")
-				return nil
-			}
-		}
-	} else {
-		// 其他平台模拟
-		_, err := d.Process.Wait()
-		if err != nil {
-			return fmt.Errorf("process wait failed: %v", err)
-		}
-		d.IsRunning = false
-		fmt.Println("Process exited")
-		return nil
+	if d.call != nil {
+		return d.run(d.finishCall)
 	}
+	return d.run(d.cont)
 }
 
 // SetBreakpoint 设置断点
 func (d *Debugger) SetBreakpoint(address uint64) error {
 	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
+		return errNotRunning
 	}
-	if runtime.GOOS == "linux" && runtime.GOARCH == "amd64" {
-		// 读取原字节
-		data := make([]byte, 1)
-		_, err := syscall.PtracePeekData(d.Process.Pid, uintptr(address), data)
-		if err != nil {
-			return fmt.Errorf("peek data failed: %v", err)
-		}
-		orig := data[0]
-		// 写入 int3
-		_, err = syscall.PtracePokeData(d.Process.Pid, uintptr(address), []byte{0xcc})
-		if err != nil {
-			return fmt.Errorf("poke data failed: %v", err)
-		}
-		d.Breakpoints[address] = &Breakpoint{
-			Address:      address,
-			OriginalByte: orig,
-			Enabled:      true,
-		}
-		fmt.Printf("Breakpoint set at 0x%x\n", address)
-		return nil
+	// 同一地址再写一次断点指令会把它当成原始字节保存下来
+	var orig []byte
+	if bp, exists := d.Breakpoints[address]; exists && !bp.internal {
+		return fmt.Errorf("breakpoint %d already set at 0x%x", bp.ID, address)
+	} else if exists {
+		// panic 捕获点等内部断点已经写入了断点指令，沿用它保存的原始字节
+		orig = bp.Original
 	} else {
-		// 其他平台模拟
-		d.Breakpoints[address] = &Breakpoint{
-			Address:      address,
-			OriginalByte: 0,
-			Enabled:      true,
+		var err error
+		if orig, err = d.insertBreakpoint(address); err != nil {
+			return err
 		}
-		fmt.Printf("Breakpoint set at 0x%x (simulation)\n", address)
-		return nil
 	}
-}
-
-// 获取 rip
-func getRegRIP(pid int) (uint64, error) {
-	var regs syscall.PtraceRegs
-	if err := syscall.PtraceGetRegs(pid, &regs); err != nil {
-		return 0, err
+	d.nextBreakpointID++
+	d.Breakpoints[address] = &Breakpoint{
+		ID:       d.nextBreakpointID,
+		Address:  address,
+		Original: orig,
+		Enabled:  true,
 	}
-	return regs.Rip, nil
-}
-
-// 设置 rip
-func setRegRIP(pid int, rip uint64) error {
-	var regs syscall.PtraceRegs
-	if err := syscall.PtraceGetRegs(pid, &regs); err != nil {
-		return err
-	}
-	regs.Rip = rip
-	return syscall.PtraceSetRegs(pid, &regs)
+	d.emit(Event{Kind: EventBreakpointSet, Breakpoint: d.nextBreakpointID, Address: address})
+	return nil
 }
 
 // RemoveBreakpoint 移除断点
 func (d *Debugger) RemoveBreakpoint(address uint64) error {
-	_, exists := d.Breakpoints[address]
+	bp, exists := d.Breakpoints[address]
 	if !exists {
 		return fmt.Errorf("no breakpoint at address 0x%x", address)
 	}
 
-	delete(d.Breakpoints, address)
-	fmt.Printf("Breakpoint removed at 0x%x\n", address)
+	if c, _ := d.panicCatchpointAt(address); c != nil {
+		// 断点指令留给捕获点继续使用
+		d.Breakpoints[address] = &Breakpoint{Address: address, Original: bp.Original, Enabled: true, internal: true}
+	} else {
+		if d.IsRunning {
+			if err := d.clearBreakpoint(bp); err != nil {
+				return err
+			}
+		}
+		delete(d.Breakpoints, address)
+	}
+	d.emit(Event{Kind: EventBreakpointRemoved, Breakpoint: bp.ID, Address: address})
 	return nil
 }
 
 // GetRegisters 获取寄存器值
 func (d *Debugger) GetRegisters() (map[string]uint64, error) {
 	if !d.IsRunning {
-		return nil, fmt.Errorf("process is not running")
+		return nil, errNotRunning
 	}
-
-	registers := make(map[string]uint64)
-
-	if runtime.GOARCH == "amd64" {
-		registers["rax"] = 0x1234567890abcdef
-		registers["rbx"] = 0xfedcba0987654321
-		registers["rcx"] = 0x1111111111111111
-		registers["rdx"] = 0x2222222222222222
-		registers["rsi"] = 0x3333333333333333
-		registers["rdi"] = 0x4444444444444444
-		registers["rbp"] = 0x7fff12345678
-		registers["rsp"] = 0x7fff12345000
-		registers["rip"] = 0x401000
-	}
-
-	return registers, nil
+	return d.getRegisters()
 }
 
 // ReadMemory 读取内存
 func (d *Debugger) ReadMemory(address uint64, size int) ([]byte, error) {
 	if !d.IsRunning {
-		return nil, fmt.Errorf("process is not running")
+		return nil, errNotRunning
 	}
-
-	data := make([]byte, size)
-	for i := range data {
-		data[i] = byte((address + uint64(i)) & 0xFF)
-	}
-
-	return data, nil
+	return d.readMemory(address, size)
 }
 
 // WriteMemory 写入内存
 func (d *Debugger) WriteMemory(address uint64, data []byte) error {
 	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
+		return errNotRunning
 	}
+	return d.writeMemory(address, data)
+}
 
-	fmt.Printf("Writing %d bytes to 0x%x (simulation)\n", len(data), address)
-	return nil
+// Interrupt 暂停正在运行的程序，阻塞中的 Continue 随后返回。
+// 与其他方法不同，它可以在其他 goroutine 中调用。
+func (d *Debugger) Interrupt() error {
+	return d.interrupt()
 }
 
-// Step 单步执行
-func (d *Debugger) Step() error {
+// Step 单步执行一条指令，返回停下的原因
+func (d *Debugger) Step() (StopReason, error) {
 	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
+		return StopReason{}, errNotRunning
 	}
+	return d.run(d.singleStep)
+}
+
+// HitBreakpoint 返回最近一次停止时命中的断点，没有则返回 nil
+func (d *Debugger) HitBreakpoint() *Breakpoint {
+	return d.hit
+}
 
-	fmt.Println("Single step executed (simulation)")
+// BreakpointByID 按编号查找断点
+func (d *Debugger) BreakpointByID(id int) *Breakpoint {
+	for _, bp := range d.Breakpoints {
+		if bp.ID == id {
+			return bp
+		}
+	}
 	return nil
 }
 
-// FindFunction 查找函数地址
+// SortedBreakpoints 按编号顺序返回所有断点
+func (d *Debugger) SortedBreakpoints() []*Breakpoint {
+	bps := make([]*Breakpoint, 0, len(d.Breakpoints))
+	for _, bp := range d.Breakpoints {
+		if !bp.internal {
+			bps = append(bps, bp)
+		}
+	}
+	sort.Slice(bps, func(i, j int) bool { return bps[i].ID < bps[j].ID })
+	return bps
+}
+
+// FindFunction 查找函数序言之后的地址，断点和捕获点都设置在这里；
+// 没有 DWARF 行号信息时返回符号表中的函数入口
 func (d *Debugger) FindFunction(name string) (uint64, error) {
+	if d.index != nil {
+		if fn := d.index.lookupFunc(name); fn != nil {
+			return d.index.prologueEnd(fn), nil
+		}
+	}
+	return d.functionEntry(name)
+}
+
+// functionEntry 查找函数入口地址，地址表达式中的函数名取入口
+func (d *Debugger) functionEntry(name string) (uint64, error) {
 	if address, exists := d.Symbols[name]; exists {
 		return address, nil
 	}
 
-	// if no symbols found, use DWARF data
 	if d.DwarfData == nil {
-		// if no symbols and no DWARF data, return a default address
-		switch name {
-		case "main":
-			return 0x401000, nil
-		case "fibonacci":
-			return 0x401100, nil
-		case "main.main":
-			return 0x401000, nil
-		case "main.fibonacci":
-			return 0x401100, nil
-		default:
-			return 0x401200, nil
-		}
+		return 0, fmt.Errorf("no DWARF data loaded")
 	}
 
-	// by using DWARF data
-	reader := d.DwarfData.Reader()
-	for {
-		entry, err := reader.Next()
+	// by using DWARF index
+	if fn := d.index.lookupFunc(name); fn != nil {
+		return fn.LowPC, nil
+	}
+
+	return 0, fmt.Errorf("function '%s' not found", name)
+}
+
+// FindLine 查找 file:line 对应的代码地址，file 可以只写文件名或路径后缀
+func (d *Debugger) FindLine(file string, line int) (uint64, error) {
+	if d.index == nil {
+		return 0, fmt.Errorf("no DWARF data loaded")
+	}
+	return d.index.lineToPC(file, line)
+}
+
+// ResolveLocation 把地址、file:line 或函数名解析为地址
+func (d *Debugger) ResolveLocation(target string) (uint64, error) {
+	// 解析为地址
+	if strings.HasPrefix(target, "0x") {
+		addr, err := strconv.ParseUint(target[2:], 16, 64)
 		if err != nil {
-			break
+			return 0, fmt.Errorf("invalid address: %s", target)
 		}
-		if entry == nil {
-			break
-		}
-
-		if entry.Tag == dwarf.TagSubprogram {
-			nameAttr := entry.Val(dwarf.AttrName)
-			if nameAttr != nil && nameAttr.(string) == name {
-				addrAttr := entry.Val(dwarf.AttrLowpc)
-				if addrAttr != nil {
-					return addrAttr.(uint64), nil
-				}
-			}
+		return addr, nil
+	}
+	if i := strings.LastIndex(target, ":"); i > 0 {
+		// 作为 file:line 查找
+		line, err := strconv.Atoi(target[i+1:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid line number: %s", target[i+1:])
 		}
+		return d.FindLine(target[:i], line)
 	}
-
-	return 0, fmt.Errorf("function '%s' not found", name)
+	// 作为函数名查找
+	return d.FindFunction(target)
 }
 
 // GetStackTrace 获取堆栈跟踪
 func (d *Debugger) GetStackTrace() ([]string, error) {
-	if !d.IsRunning {
-		return nil, fmt.Errorf("process is not running")
+	frames, err := d.Stack()
+	if err != nil {
+		return nil, err
 	}
 
-	// 返回模拟堆栈
-	stackTrace := []string{
-		"0x401000 main.main",
-		"0x401100 main.fibonacci",
-		"0x401110 main.fibonacci",
-		"0x401120 runtime.main",
+	stackTrace := make([]string, len(frames))
+	for i, frame := range frames {
+		stackTrace[i] = frame.String()
 	}
-
 	return stackTrace, nil
 }
 
+// Stack 回溯当前线程的调用栈
+func (d *Debugger) Stack() ([]Frame, error) {
+	if !d.IsRunning {
+		return nil, errNotRunning
+	}
+	if !d.Capabilities().StackTrace {
+		return nil, unsupported("stack trace")
+	}
+	return d.stacktrace(maxStackDepth)
+}
+
+// registerList 按架构定义的顺序返回当前线程的寄存器
+func (d *Debugger) registerList() ([]namedRegister, error) {
+	if !d.IsRunning {
+		return nil, errNotRunning
+	}
+	regs, err := d.registers()
+	if err != nil {
+		return nil, err
+	}
+	return regs.Named(), nil
+}
+
 // Detach 从进程分离
 func (d *Debugger) Detach() error {
 	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
+		return errNotRunning
 	}
 
-	fmt.Println("Detached from process (simulation)")
+	if err := d.callPending(); err != nil {
+		return err
+	}
+	if err := d.detach(); err != nil {
+		return err
+	}
 	d.IsRunning = false
 	return nil
 }
 
-// Kill
+// Kill 杀死进程并回收它的所有线程，检查点进程随之删除
 func (d *Debugger) Kill() error {
 	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
+		return errNotRunning
 	}
 
-	if err := d.Process.Kill(); err != nil {
+	if err := d.killTracee(); err != nil {
 		return fmt.Errorf("failed to kill process: %v", err)
 	}
+	d.killCheckpoints()
+	d.hit = nil
+	d.record = nil
+	d.call = nil
 
-	d.IsRunning = false
-	fmt.Println("Process killed")
+	d.emit(Event{Kind: EventKilled, PID: d.Process.Pid})
 	return nil
 }
 
-// FindVariableAddress 查找变量的内存地址（支持当前函数局部变量和全局变量）
-// frameBase: 当前帧指针（rbp），currentFunc: 当前函数名。若 frameBase=0 或 currentFunc=""，只查全局变量。
-func (d *Debugger) FindVariableAddress(name string, frameBase uint64, currentFunc string) (uint64, error) {
+// FindVariableAddress 查找变量的内存地址：先查帧 frame 所在函数的局部变量和参数，再查全局变量。
+// frame 为 nil 时只查全局变量。Go 的局部变量相对帧的 CFA 定位（DW_AT_frame_base 为 DW_OP_call_frame_cfa）。
+func (d *Debugger) FindVariableAddress(name string, frame *Frame) (uint64, error) {
+	_, loc, err := d.findVariable(name, frame)
+	if err != nil {
+		return 0, err
+	}
+	if loc.data != nil {
+		return 0, fmt.Errorf("variable '%s' is held in a register and has no address", name)
+	}
+	return loc.addr, nil
+}
+
+// findVariable 查找变量的 DIE 并计算它在帧 frame 中的位置
+func (d *Debugger) findVariable(name string, frame *Frame) (*dwarf.Entry, location, error) {
 	if d.DwarfData == nil {
-		return 0, fmt.Errorf("no DWARF data loaded")
+		return nil, location{}, fmt.Errorf("no DWARF data loaded")
 	}
-	reader := d.DwarfData.Reader()
-	var inFunc bool
-	for {
-		entry, err := reader.Next()
-		if err != nil {
-			break
-		}
-		if entry == nil {
-			break
-		}
-		// 进入当前函数作用域
-		if entry.Tag == dwarf.TagSubprogram {
-			nameAttr := entry.Val(dwarf.AttrName)
-			if nameAttr != nil && nameAttr.(string) == currentFunc {
-				inFunc = true
-			} else {
-				inFunc = false
-			}
-		}
-		// 局部变量或参数
-		if inFunc && (entry.Tag == dwarf.TagVariable || entry.Tag == dwarf.TagFormalParameter) {
-			nameAttr := entry.Val(dwarf.AttrName)
-			if nameAttr != nil && nameAttr.(string) == name {
-				locAttr := entry.Val(dwarf.AttrLocation)
-				if locAttr != nil {
-					loc, ok := locAttr.([]byte)
-					// 支持 DW_OP_fbreg
-					if ok && len(loc) >= 2 && loc[0] == 0x91 && frameBase != 0 { // DW_OP_fbreg
-						// DW_OP_fbreg: 0x91 <sleb128 offset>
-						offset := int8(loc[1]) // sleb128 简化处理
-						addr := frameBase + uint64(offset)
-						return addr, nil
-					}
+	// 局部变量或参数
+	if frame != nil {
+		if fn := d.index.funcByPC(frame.lookupPC()); fn != nil {
+			for _, entry := range d.index.scopeVariables(fn, frame.lookupPC()) {
+				if entry.Val(dwarf.AttrName).(string) != name {
+					continue
+				}
+				loc, err := d.variableLocation(entry, fn, frame)
+				if err != nil {
+					return nil, location{}, fmt.Errorf("variable '%s': %v", name, err)
 				}
+				return entry, loc, nil
 			}
 		}
 	}
 	// 没找到局部变量，查全局变量
-	reader = d.DwarfData.Reader()
-	for {
-		entry, err := reader.Next()
-		if err != nil {
-			break
-		}
-		if entry == nil {
-			break
-		}
-		if entry.Tag == dwarf.TagVariable {
-			nameAttr := entry.Val(dwarf.AttrName)
-			if nameAttr != nil && nameAttr.(string) == name {
-				locAttr := entry.Val(dwarf.AttrLocation)
-				if locAttr != nil {
-					loc, ok := locAttr.([]byte)
-					if ok && len(loc) >= 9 && loc[0] == 3 { // DW_OP_addr
-						addr := uint64(loc[1]) | uint64(loc[2])<<8 | uint64(loc[3])<<16 | uint64(loc[4])<<24 |
-							uint64(loc[5])<<32 | uint64(loc[6])<<40 | uint64(loc[7])<<48 | uint64(loc[8])<<56
-						return addr, nil
-					}
-				}
+	entry, err := d.index.lookupGlobal(name)
+	if err != nil {
+		return nil, location{}, err
+	}
+	loc, err := d.variableLocation(entry, nil, nil)
+	if err != nil {
+		return nil, location{}, fmt.Errorf("variable '%s': %v", name, err)
+	}
+	return entry, loc, nil
+}
+
+// FunctionAt 返回包含 pc 的函数名
+func (d *Debugger) FunctionAt(pc uint64) (string, error) {
+	if d.index == nil {
+		return "", fmt.Errorf("no DWARF data")
+	}
+	fn := d.index.funcByPC(pc)
+	if fn == nil {
+		return "", fmt.Errorf("func not found for rip")
+	}
+	return fn.Name, nil
+}
+
+// decodeSLEB128 解码有符号 LEB128，返回值和占用的字节数
+func decodeSLEB128(buf []byte) (int64, int) {
+	var result int64
+	var shift uint
+	for i, b := range buf {
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
 			}
+			return result, i + 1
 		}
 	}
-	return 0, fmt.Errorf("variable '%s' not found or unsupported location", name)
+	return result, len(buf)
 }
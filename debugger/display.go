@@ -0,0 +1,177 @@
+package debugger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// display 每次程序停下时自动求值并打印的表达式
+type display struct {
+	ID     int
+	Expr   string
+	Format string // display/<fmt> 的格式，如 "/i"、"/x"
+}
+
+// displayValue 一次求值的结果，Rows 用于带格式的 display
+type displayValue struct {
+	ID     int          `json:"id"`
+	Expr   string       `json:"expr"`
+	Format string       `json:"format,omitempty"`
+	Type   string       `json:"type,omitempty"`
+	Value  string       `json:"value,omitempty"`
+	Rows   []examineRow `json:"rows,omitempty"`
+	Error  string       `json:"error,omitempty"`
+
+	examine *examineResult
+}
+
+func (v displayValue) printText(w io.Writer) {
+	switch {
+	case v.Error != "":
+		fmt.Fprintf(w, "%d: %s%s = <error: %s>\n", v.ID, displayCommand(v.Format), v.Expr, v.Error)
+	case v.examine != nil:
+		fmt.Fprintf(w, "%d: x%s %s\n", v.ID, v.Format, v.Expr)
+		v.examine.printText(w)
+	default:
+		fmt.Fprintf(w, "%d: %s%s = %s\n", v.ID, displayCommand(v.Format), v.Expr, v.Value)
+	}
+}
+
+// displayCommand 读取内存的 display 在输出中显示为 x/<fmt>，按格式打印值的显示为 /<fmt>
+func displayCommand(format string) string {
+	switch {
+	case format == "":
+		return ""
+	case displayExamines(format):
+		return "x" + format + " "
+	}
+	return format + " "
+}
+
+// displayExamines 与 gdb 相同，格式为 i 或 s、或者指定了数量或单位时按 x 命令读取内存，
+// 否则按格式打印表达式的值
+func displayExamines(format string) bool {
+	return strings.ContainsAny(format, "is0123456789bhwg")
+}
+
+type displaysResult struct {
+	Displays []displayValue `json:"displays"`
+}
+
+func (res displaysResult) printText(w io.Writer) {
+	for _, v := range res.Displays {
+		v.printText(w)
+	}
+}
+
+// cmdDisplay display[/fmt] <expr> 添加自动显示的表达式并立即求值，不带参数时显示全部
+func (r *REPL) cmdDisplay(args []string) error {
+	var format string
+	if len(args) > 0 && strings.HasPrefix(args[0], "/") {
+		if _, err := parseExamineFormat(args[0]); err != nil {
+			return err
+		}
+		format, args = args[0], args[1:]
+	}
+	if len(args) == 0 {
+		if format != "" {
+			return fmt.Errorf("usage: display[/fmt] <expression>")
+		}
+		r.output(displaysResult{Displays: r.evalDisplays()})
+		return nil
+	}
+
+	r.nextDisplayID++
+	disp := display{ID: r.nextDisplayID, Expr: strings.Join(args, " "), Format: format}
+	r.displays = append(r.displays, disp)
+	if r.Debugger != nil && r.Debugger.IsRunning {
+		r.output(displaysResult{Displays: []displayValue{r.evalDisplay(disp)}})
+	} else {
+		r.output(messageResult{Message: fmt.Sprintf("Display %d: %s%s", disp.ID, displayCommand(format), disp.Expr)})
+	}
+	return nil
+}
+
+// cmdUndisplay undisplay [id...] 删除自动显示的表达式，不带参数时全部删除
+func (r *REPL) cmdUndisplay(args []string) error {
+	if len(args) == 0 {
+		r.displays = nil
+		r.output(messageResult{Message: "Deleted all displays"})
+		return nil
+	}
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid display number: %s", arg)
+		}
+		found := false
+		for i, disp := range r.displays {
+			if disp.ID == id {
+				r.displays = append(r.displays[:i], r.displays[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no display number %d", id)
+		}
+	}
+	r.output(messageResult{Message: fmt.Sprintf("Deleted display %s", strings.Join(args, " "))})
+	return nil
+}
+
+// evalDisplays 在当前帧中对所有表达式求值，程序未运行时返回 nil
+func (r *REPL) evalDisplays() []displayValue {
+	if r.Debugger == nil || !r.Debugger.IsRunning {
+		return nil
+	}
+	values := make([]displayValue, 0, len(r.displays))
+	for _, disp := range r.displays {
+		values = append(values, r.evalDisplay(disp))
+	}
+	return values
+}
+
+// evalDisplay 读取内存的格式按 x 命令求值，否则优先按变量读取，其次按地址表达式求值，
+// 带格式时值按该格式输出
+func (r *REPL) evalDisplay(disp display) displayValue {
+	v := displayValue{ID: disp.ID, Expr: disp.Expr, Format: disp.Format}
+	f, _ := parseExamineFormat(disp.Format)
+	if disp.Format != "" && displayExamines(disp.Format) {
+		res, err := r.examine(f, disp.Expr)
+		if err != nil {
+			v.Error = err.Error()
+			return v
+		}
+		v.Rows, v.examine = res.Rows, &res
+		return v
+	}
+	var radix byte
+	if disp.Format != "" {
+		radix = f.format
+	}
+
+	variable, varErr := r.Debugger.ReadVariableFormat(disp.Expr, r.frame(), radix)
+	if varErr == nil {
+		v.Type, v.Value = variable.Type, variable.Value
+		return v
+	}
+	addr, err := r.evalAddress(disp.Expr)
+	if err != nil {
+		// 单独的名字按变量报错，而不是函数查找的错误
+		if c := disp.Expr[0]; c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+			err = varErr
+		}
+		v.Error = err.Error()
+		return v
+	}
+	if radix != 0 {
+		v.Value = formatRadix(binary.LittleEndian.AppendUint64(nil, addr), radix)
+	} else {
+		v.Value = fmt.Sprintf("0x%x", addr)
+	}
+	return v
+}
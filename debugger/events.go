@@ -0,0 +1,137 @@
+package debugger
+
+import (
+	"fmt"
+)
+
+// EventKind 调试事件的类型
+type EventKind string
+
+const (
+	EventStarted           EventKind = "started"
+	EventBreakpointSet     EventKind = "breakpoint_set"
+	EventBreakpointRemoved EventKind = "breakpoint_removed"
+	EventBreakpointHit     EventKind = "breakpoint_hit"
+	EventInterrupted       EventKind = "interrupted"
+	EventSignal            EventKind = "signal"
+	EventStopped           EventKind = "stopped"
+	EventExited            EventKind = "exited"
+	EventKilled            EventKind = "killed"
+	EventRestarted         EventKind = "restarted"
+	EventHistoryStart      EventKind = "history_start"
+	EventOutput            EventKind = "output"
+	EventFork              EventKind = "fork"
+	EventExec              EventKind = "exec"
+	EventSyscallEntry      EventKind = "syscall_entry"
+	EventSyscallReturn     EventKind = "syscall_return"
+	EventPanic             EventKind = "panic"
+	EventCallStopped       EventKind = "call_stopped"  // 程序在 call 注入的调用中停下
+	EventCallReturned      EventKind = "call_returned" // continue 完成了停下的调用
+)
+
+// Event 调试器在执行命令或程序运行过程中产生的事件，
+// 文本模式下打印 String()，JSON 模式下随命令结果一起输出
+type Event struct {
+	Kind       EventKind `json:"kind"`
+	PID        int       `json:"pid,omitempty"`
+	Thread     int       `json:"thread,omitempty"`
+	Breakpoint int       `json:"breakpoint,omitempty"`
+	Checkpoint int       `json:"checkpoint,omitempty"`
+	Address    uint64    `json:"address,omitempty"`
+	Signal     string    `json:"signal,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	Frame      *Frame    `json:"frame,omitempty"`
+	Output     string    `json:"output,omitempty"`
+	Child      int       `json:"child,omitempty"`
+	Executable string    `json:"executable,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Catchpoint int       `json:"catchpoint,omitempty"`
+	Syscall    string    `json:"syscall,omitempty"` // 系统调用名
+	Call       string    `json:"call,omitempty"`    // 解码了参数的系统调用，例如 openat(AT_FDCWD, "/etc/hosts", O_RDONLY)，或者 call 调用的函数名
+	Return     string    `json:"return,omitempty"`  // 返回值，系统调用出错时带有 errno 的名字
+	Panic      string    `json:"panic,omitempty"`   // panic 的值或致命错误的信息
+	Stack      []Frame   `json:"stack,omitempty"`   // 停在 panic 捕获点时 goroutine 的调用栈
+}
+
+func (e Event) String() string {
+	var s string
+	switch e.Kind {
+	case EventStarted:
+		s = fmt.Sprintf("Process started with PID: %d", e.PID)
+	case EventBreakpointSet:
+		s = fmt.Sprintf("Breakpoint %d set at 0x%x", e.Breakpoint, e.Address)
+	case EventBreakpointRemoved:
+		s = fmt.Sprintf("Breakpoint removed at 0x%x", e.Address)
+	case EventBreakpointHit:
+		s = fmt.Sprintf("Hit breakpoint %d at 0x%x", e.Breakpoint, e.Address)
+	case EventInterrupted:
+		s = "Program interrupted."
+	case EventSignal:
+		sig, _ := parseSignal(e.Signal)
+		s = fmt.Sprintf("Thread %d received signal %s, %s.", e.Thread, e.Signal, signalDesc(sig))
+	case EventStopped:
+		s = fmt.Sprintf("Stopped (%s)", e.Signal)
+	case EventExited:
+		s = "Process exited"
+		if e.ExitCode != nil {
+			s = fmt.Sprintf("Process exited with code %d", *e.ExitCode)
+		}
+	case EventKilled:
+		if e.Signal != "" {
+			s = fmt.Sprintf("Process killed by signal %s", e.Signal)
+		} else {
+			s = "Process killed"
+		}
+	case EventRestarted:
+		s = fmt.Sprintf("Switched to checkpoint %d (process %d)", e.Checkpoint, e.PID)
+	case EventHistoryStart:
+		s = "No more reverse-execution history."
+	case EventOutput:
+		s = e.Output
+	case EventFork:
+		s = fmt.Sprintf("Process %d forked child process %d: %s.", e.PID, e.Child, e.Message)
+	case EventExec:
+		s = fmt.Sprintf("Process %d is executing new program: %s", e.PID, e.Executable)
+		if e.Message != "" {
+			s += " (" + e.Message + ")"
+		}
+	case EventSyscallEntry:
+		s = fmt.Sprintf("Catchpoint %d (call to syscall %s), thread %d: %s", e.Catchpoint, e.Syscall, e.Thread, e.Call)
+	case EventSyscallReturn:
+		s = fmt.Sprintf("Catchpoint %d (returned from syscall %s), thread %d: %s = %s", e.Catchpoint, e.Syscall, e.Thread, e.Call, e.Return)
+	case EventPanic:
+		s = fmt.Sprintf("Catchpoint %d (%s), thread %d", e.Catchpoint, e.Message, e.Thread)
+		if e.Panic != "" {
+			s += ": " + e.Panic
+		}
+	case EventCallStopped:
+		s = fmt.Sprintf("The program stopped in a function called from the debugger (%s); 'continue' finishes the call.", e.Call)
+	case EventCallReturned:
+		switch {
+		case e.Message != "":
+			s = fmt.Sprintf("Call to %s failed: %s", e.Call, e.Message)
+		case e.Return != "":
+			s = fmt.Sprintf("Call to %s returned %s", e.Call, e.Return)
+		default:
+			s = fmt.Sprintf("Call to %s returned", e.Call)
+		}
+	default:
+		s = string(e.Kind)
+	}
+	if e.Frame != nil {
+		s += "\n" + e.Frame.String()
+	}
+	for i, frame := range e.Stack {
+		s += fmt.Sprintf("\n  #%d: %s", i, frame)
+	}
+	return s
+}
+
+// emit 把事件交给 OnEvent，没有设置时直接打印
+func (d *Debugger) emit(e Event) {
+	if d.OnEvent != nil {
+		d.OnEvent(e)
+		return
+	}
+	fmt.Println(e.String())
+}
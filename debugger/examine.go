@@ -0,0 +1,343 @@
+package debugger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxExamineString x/s 读取字符串的最大长度
+const maxExamineString = 256
+
+// maxExamineBytes x 和 memory 一次最多读取的字节数
+const maxExamineBytes = 64 * 1024
+
+// examineFormat x 命令的 /<count><format><unit>
+type examineFormat struct {
+	count  int
+	format byte // x d u o t c s i
+	unit   int  // 字节数：b=1 h=2 w=4 g=8
+}
+
+// parseExamineFormat 解析 "/4xg" 形式的格式，数字在前，格式和单位字母顺序任意
+func parseExamineFormat(spec string) (examineFormat, error) {
+	f := examineFormat{count: 1, format: 'x', unit: 4}
+	spec = strings.TrimPrefix(spec, "/")
+	i := 0
+	for i < len(spec) && spec[i] >= '0' && spec[i] <= '9' {
+		i++
+	}
+	if i > 0 {
+		n, err := strconv.Atoi(spec[:i])
+		if err != nil || n <= 0 {
+			return f, fmt.Errorf("invalid count: %s", spec[:i])
+		}
+		f.count = n
+	}
+	for _, c := range []byte(spec[i:]) {
+		switch c {
+		case 'x', 'd', 'u', 'o', 't', 'c', 's', 'i':
+			f.format = c
+		case 'b':
+			f.unit = 1
+		case 'h':
+			f.unit = 2
+		case 'w':
+			f.unit = 4
+		case 'g':
+			f.unit = 8
+		default:
+			return f, fmt.Errorf("invalid format letter '%c' (formats: x d u o t c s i, units: b h w g)", c)
+		}
+	}
+	if f.format == 'c' {
+		f.unit = 1
+	}
+	if f.count > maxExamineBytes/f.unit {
+		return f, fmt.Errorf("count %d too large: x reads at most %d bytes at a time", f.count, maxExamineBytes)
+	}
+	return f, nil
+}
+
+// examineRow 输出的一行：起始地址、所在符号以及若干个值（或一条指令）
+type examineRow struct {
+	Address uint64   `json:"address"`
+	Symbol  string   `json:"symbol,omitempty"`
+	Values  []string `json:"values"`
+}
+
+type examineResult struct {
+	Format string       `json:"format"`
+	Unit   int          `json:"unit"`
+	Rows   []examineRow `json:"rows"`
+	// x/Nxb 按字节查看时沿用 memory 的十六进制 + ASCII 视图
+	dump    []byte
+	address uint64
+}
+
+func (res examineResult) printText(w io.Writer) {
+	if res.dump != nil {
+		printMemoryDump(w, res.address, res.dump)
+		return
+	}
+	for _, row := range res.Rows {
+		prefix := fmt.Sprintf("0x%x", row.Address)
+		if row.Symbol != "" {
+			prefix += " <" + row.Symbol + ">"
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", prefix, strings.Join(row.Values, "\t"))
+	}
+}
+
+// cmdExamine x/<count><format><unit> <addr|expr>
+func (r *REPL) cmdExamine(args []string) error {
+	f := examineFormat{count: 1, format: 'x', unit: 4}
+	if len(args) > 0 && strings.HasPrefix(args[0], "/") {
+		var err error
+		if f, err = parseExamineFormat(args[0]); err != nil {
+			return err
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: x/<count><format><unit> <address|expression>")
+	}
+	res, err := r.examine(f, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	r.output(res)
+	return nil
+}
+
+// examine 按格式 f 读取表达式 expr 指向的内存
+func (r *REPL) examine(f examineFormat, expr string) (examineResult, error) {
+	addr, err := r.evalAddress(expr)
+	if err != nil {
+		return examineResult{}, err
+	}
+
+	res := examineResult{Format: string(f.format), Unit: f.unit, address: addr}
+	switch f.format {
+	case 's':
+		res.Unit = 1
+		for i := 0; i < f.count; i++ {
+			s, err := r.readCString(addr)
+			if err != nil {
+				return res, err
+			}
+			res.Rows = append(res.Rows, examineRow{Address: addr, Symbol: r.symbolAt(addr), Values: []string{strconv.Quote(s)}})
+			addr += uint64(len(s)) + 1
+		}
+	case 'i':
+		res.Unit = 0
+		if res.Rows, err = r.disassemble(addr, f.count); err != nil {
+			return res, err
+		}
+	default:
+		data, err := r.Debugger.ReadMemory(addr, f.count*f.unit)
+		if err != nil {
+			return res, err
+		}
+		if f.format == 'x' && f.unit == 1 {
+			res.dump = data
+		}
+		res.Rows = formatUnits(addr, data, f, r.symbolAt)
+	}
+	return res, nil
+}
+
+// formatUnits 按 gdb 的排版每行输出若干个单位
+func formatUnits(addr uint64, data []byte, f examineFormat, symbol func(uint64) string) []examineRow {
+	perRow := map[int]int{1: 8, 2: 8, 4: 4, 8: 2}[f.unit]
+	var rows []examineRow
+	for off := 0; off < len(data); off += f.unit {
+		if (off/f.unit)%perRow == 0 {
+			a := addr + uint64(off)
+			rows = append(rows, examineRow{Address: a, Symbol: symbol(a)})
+		}
+		row := &rows[len(rows)-1]
+		row.Values = append(row.Values, formatUnit(data[off:off+f.unit], f))
+	}
+	return rows
+}
+
+// formatUnit 格式化一个小端序的单位
+func formatUnit(b []byte, f examineFormat) string {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	bits := uint(len(b) * 8)
+	signed := int64(v<<(64-bits)) >> (64 - bits)
+	switch f.format {
+	case 'd':
+		return strconv.FormatInt(signed, 10)
+	case 'u':
+		return strconv.FormatUint(v, 10)
+	case 'o':
+		return "0" + strconv.FormatUint(v, 8)
+	case 't':
+		return fmt.Sprintf("%0*b", bits, v)
+	case 'c':
+		return fmt.Sprintf("%d %s", signed, strconv.QuoteRuneToASCII(rune(v)))
+	}
+	return fmt.Sprintf("0x%0*x", len(b)*2, v)
+}
+
+// readCString 读取以 NUL 结尾的字符串，最多 maxExamineString 字节
+func (r *REPL) readCString(addr uint64) (string, error) {
+	var buf []byte
+	for len(buf) < maxExamineString {
+		chunk, err := r.Debugger.ReadMemory(addr+uint64(len(buf)), 16)
+		if err != nil {
+			if len(buf) > 0 {
+				break
+			}
+			return "", err
+		}
+		if i := strings.IndexByte(string(chunk), 0); i >= 0 {
+			return string(append(buf, chunk[:i]...)), nil
+		}
+		buf = append(buf, chunk...)
+	}
+	if len(buf) > maxExamineString {
+		buf = buf[:maxExamineString]
+	}
+	return string(buf), nil
+}
+
+// disassemble 从 addr 开始反汇编 count 条指令
+func (r *REPL) disassemble(addr uint64, count int) ([]examineRow, error) {
+	d := r.Debugger
+	if d.arch == nil {
+		return nil, unsupported("disassembly")
+	}
+	symbol := func(a uint64) (string, uint64) {
+		if d.index == nil {
+			return "", 0
+		}
+		if fn := d.index.funcByPC(a); fn != nil {
+			return fn.Name, fn.LowPC
+		}
+		return "", 0
+	}
+
+	var rows []examineRow
+	for i := 0; i < count; i++ {
+		// 最长的 x86 指令为 15 字节
+		code, err := d.ReadMemory(addr, 16)
+		if err != nil {
+			return nil, err
+		}
+		text, size, err := d.arch.Disassemble(code, addr, symbol)
+		if err != nil {
+			text, size = fmt.Sprintf("(bad) %v", err), 1
+		}
+		rows = append(rows, examineRow{Address: addr, Symbol: r.symbolAt(addr), Values: []string{text}})
+		addr += uint64(size)
+	}
+	return rows, nil
+}
+
+// symbolAt 把地址格式化为 函数名+偏移
+func (r *REPL) symbolAt(addr uint64) string {
+	d := r.Debugger
+	if d == nil || d.index == nil {
+		return ""
+	}
+	fn := d.index.funcByPC(addr)
+	if fn == nil {
+		return ""
+	}
+	if addr == fn.LowPC {
+		return fn.Name
+	}
+	return fmt.Sprintf("%s+%d", fn.Name, addr-fn.LowPC)
+}
+
+// evalAddress 计算地址表达式：数字、$寄存器、&变量、函数名，可用 + - 连接，如 $rsp+8
+func (r *REPL) evalAddress(expr string) (uint64, error) {
+	expr = strings.ReplaceAll(expr, " ", "")
+	if expr == "" {
+		return 0, fmt.Errorf("empty expression")
+	}
+	var result uint64
+	sign := uint64(1)
+	start := 0
+	for i := 0; i <= len(expr); i++ {
+		if i < len(expr) && (expr[i] != '+' && expr[i] != '-' || i == start) {
+			continue
+		}
+		v, err := r.evalTerm(expr[start:i])
+		if err != nil {
+			return 0, err
+		}
+		result += sign * v
+		if i < len(expr) {
+			sign = 1
+			if expr[i] == '-' {
+				sign = ^uint64(0) // -1
+			}
+		}
+		start = i + 1
+	}
+	return result, nil
+}
+
+// evalTerm 计算表达式中的一项
+func (r *REPL) evalTerm(term string) (uint64, error) {
+	if term == "" {
+		return 0, fmt.Errorf("invalid expression")
+	}
+	switch {
+	case term[0] >= '0' && term[0] <= '9':
+		v, err := strconv.ParseUint(term, 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number: %s", term)
+		}
+		return v, nil
+	case term[0] == '$':
+		return r.registerValue(term[1:])
+	case term[0] == '&':
+		return r.Debugger.FindVariableAddress(term[1:], r.frame())
+	case term[0] == '*':
+		addr, err := r.evalTerm(term[1:])
+		if err != nil {
+			return 0, err
+		}
+		data, err := r.Debugger.ReadMemory(addr, 8)
+		if err != nil {
+			return 0, err
+		}
+		return binary.LittleEndian.Uint64(data), nil
+	}
+	return r.Debugger.functionEntry(term)
+}
+
+// registerValue 按名字读取当前线程的寄存器，支持 pc/sp/fp 通用别名
+func (r *REPL) registerValue(name string) (uint64, error) {
+	if !r.Debugger.IsRunning {
+		return 0, errNotRunning
+	}
+	regs, err := r.Debugger.registers()
+	if err != nil {
+		return 0, err
+	}
+	switch name {
+	case "pc":
+		return regs.PC(), nil
+	case "sp":
+		return regs.SP(), nil
+	case "fp":
+		return regs.FP(), nil
+	}
+	for _, reg := range regs.Named() {
+		if reg.Name == name {
+			return reg.Value, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown register: $%s", name)
+}
@@ -0,0 +1,38 @@
+package debugger
+
+import "testing"
+
+func TestParseExamineFormat(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    examineFormat
+		wantErr bool
+	}{
+		{spec: "/", want: examineFormat{count: 1, format: 'x', unit: 4}},
+		{spec: "/4xg", want: examineFormat{count: 4, format: 'x', unit: 8}},
+		{spec: "/gx4", wantErr: true},
+		{spec: "/bd", want: examineFormat{count: 1, format: 'd', unit: 1}},
+		{spec: "/10i", want: examineFormat{count: 10, format: 'i', unit: 4}},
+		{spec: "/s", want: examineFormat{count: 1, format: 's', unit: 4}},
+		// c 总是按字节读取
+		{spec: "/3cg", want: examineFormat{count: 3, format: 'c', unit: 1}},
+		{spec: "/2hu", want: examineFormat{count: 2, format: 'u', unit: 2}},
+		{spec: "/0x", wantErr: true},
+		{spec: "/4z", wantErr: true},
+		{spec: "/65536xb", want: examineFormat{count: 65536, format: 'x', unit: 1}},
+		{spec: "/65537xb", wantErr: true},
+		{spec: "/99999999999999999999x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseExamineFormat(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseExamineFormat(%q) = %+v, want error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseExamineFormat(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+)
+
+// FollowForkMode 程序 fork 后调试器跟随的进程
+type FollowForkMode string
+
+const (
+	FollowParent FollowForkMode = "parent"
+	FollowChild  FollowForkMode = "child"
+)
+
+// ForkSettings 程序 fork 时的处理方式，与 gdb 的 follow-fork-mode、detach-on-fork 相同
+type ForkSettings struct {
+	FollowMode FollowForkMode
+	// Detach 为 true 时不跟随的一方移除断点后脱离调试器继续运行，
+	// 否则保持暂停（调试器退出时被杀死）
+	Detach bool
+}
+
+func NewForkSettings() *ForkSettings {
+	return &ForkSettings{FollowMode: FollowParent, Detach: true}
+}
+
+// pendingFork stopAll 或单步期间收到的 fork 事件，线程停在事件处，下次恢复运行前处理
+type pendingFork struct {
+	tid   int
+	vfork bool
+}
+
+// heldProcess 调试器暂停着的另一个进程及其线程
+type heldProcess struct {
+	pid   int
+	tids  []int
+	vfork bool // vfork 的子进程，与父进程共享内存
+}
+
+// Inferior 调试器控制的一个进程
+type Inferior struct {
+	PID        int    `json:"pid"`
+	Executable string `json:"executable"`
+	Current    bool   `json:"current"`
+	Held       bool   `json:"held,omitempty"`  // fork 后保持暂停，可以用 DetachInferior 放开
+	Vfork      bool   `json:"vfork,omitempty"` // vfork 的子进程，放开之前父进程无法继续运行
+}
+
+// Inferiors 返回当前进程和 fork 后保持暂停的进程。保持暂停的进程停在 fork 处，
+// 还没有 exec，程序与当前进程相同
+func (d *Debugger) Inferiors() []Inferior {
+	var list []Inferior
+	if d.IsRunning {
+		list = append(list, Inferior{PID: d.Process.Pid, Executable: d.Executable, Current: true})
+	}
+	for _, p := range d.held {
+		list = append(list, Inferior{PID: p.pid, Executable: d.Executable, Held: true, Vfork: p.vfork})
+	}
+	return list
+}
+
+// vforkBlocked 父进程停在 vfork 中等待保持暂停的子进程，这时不能让它继续运行（与 gdb 相同）
+func (d *Debugger) vforkBlocked() error {
+	if d.vforkChild == 0 {
+		return nil
+	}
+	return fmt.Errorf("cannot resume the parent process over vfork while holding child %d stopped; use 'detach inferior %d' first", d.vforkChild, d.vforkChild)
+}
+
+// setOption 处理 set 的调试器设置项，args[0] 不是设置项时返回 false
+func (r *REPL) setOption(args []string) (bool, error) {
+	var value string
+	switch args[0] {
+	case "follow-fork-mode":
+		if len(args) > 1 {
+			switch mode := FollowForkMode(args[1]); mode {
+			case FollowParent, FollowChild:
+				r.fork.FollowMode = mode
+			default:
+				return true, fmt.Errorf("usage: set follow-fork-mode parent|child")
+			}
+		}
+		value = string(r.fork.FollowMode)
+	case "detach-on-fork":
+		if len(args) > 1 {
+			switch args[1] {
+			case "on":
+				r.fork.Detach = true
+			case "off":
+				r.fork.Detach = false
+			default:
+				return true, fmt.Errorf("usage: set detach-on-fork on|off")
+			}
+		}
+		value = "off"
+		if r.fork.Detach {
+			value = "on"
+		}
+	default:
+		return false, nil
+	}
+	r.output(messageResult{Message: fmt.Sprintf("%s is %s.", args[0], value)})
+	return true, nil
+}
+
+// cmdInfo info [breakpoints|inferiors]，不带参数时列出断点
+func (r *REPL) cmdInfo(args []string) error {
+	if len(args) == 0 || args[0] == "breakpoints" {
+		return r.cmdBreakpoints(nil)
+	}
+	if args[0] != "inferiors" {
+		return fmt.Errorf("usage: info [breakpoints|inferiors]")
+	}
+	r.output(inferiorsResult{Inferiors: append([]Inferior{}, r.Debugger.Inferiors()...)})
+	return nil
+}
+
+// inferiorsResult info inferiors 的输出
+type inferiorsResult struct {
+	Inferiors []Inferior `json:"inferiors"`
+}
+
+func (res inferiorsResult) printText(w io.Writer) {
+	if len(res.Inferiors) == 0 {
+		fmt.Fprintln(w, "No processes.")
+		return
+	}
+	for _, inf := range res.Inferiors {
+		mark, state := " ", "held"
+		switch {
+		case inf.Current:
+			mark, state = "*", "current"
+		case inf.Vfork:
+			state = "held, vfork child"
+		}
+		fmt.Fprintf(w, "%s process %d (%s) %s\n", mark, inf.PID, state, inf.Executable)
+	}
+}
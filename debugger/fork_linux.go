@@ -0,0 +1,273 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ptraceOptions 跟踪新线程、fork/vfork 出的子进程和 exec，系统调用停止与断点区分开，
+// 调试器退出时杀死被调试进程
+const ptraceOptions = syscall.PTRACE_O_TRACECLONE | syscall.PTRACE_O_TRACEFORK | syscall.PTRACE_O_TRACEVFORK |
+	syscall.PTRACE_O_TRACEVFORKDONE | syscall.PTRACE_O_TRACEEXEC | syscall.PTRACE_O_TRACESYSGOOD | ptraceOExitKill
+
+// isOtherProcess 判断暂停的 pid 是 fork 出的另一个进程，而不是当前进程的新线程
+func (d *Debugger) isOtherProcess(pid int) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "Tgid:"); ok {
+			tgid, err := strconv.Atoi(strings.TrimSpace(v))
+			return err == nil && tgid != d.Process.Pid
+		}
+	}
+	return false
+}
+
+// earlyForkStop 子进程的初始暂停可能先于父进程的 fork 事件到达，记下来留给 handleFork 处理
+func (d *Debugger) earlyForkStop(pid int) bool {
+	if _, ok := d.threads[pid]; ok || !d.isOtherProcess(pid) {
+		return false
+	}
+	d.forkStops[pid] = true
+	return true
+}
+
+// handleFork 处理线程 tid 上的 PTRACE_EVENT_FORK/VFORK。子进程继承了断点指令，
+// 按 follow-fork-mode 和 detach-on-fork 决定跟随哪一方，另一方移除断点后脱离或保持暂停。
+// 返回是否切换到了子进程；线程的恢复由调用者负责。
+func (d *Debugger) handleFork(tid int, vfork bool) (bool, error) {
+	msg, err := syscall.PtraceGetEventMsg(tid)
+	if err != nil {
+		return false, fmt.Errorf("ptrace geteventmsg failed: %v", err)
+	}
+	child := int(msg)
+	if !d.forkStops[child] {
+		var ws syscall.WaitStatus
+		if _, err := syscall.Wait4(child, &ws, syscall.WALL, nil); err != nil {
+			return false, fmt.Errorf("wait4 %d failed: %v", child, err)
+		}
+		if !ws.Stopped() {
+			return false, nil
+		}
+	}
+	delete(d.forkStops, child)
+	parent := d.Process.Pid
+	event := Event{Kind: EventFork, PID: parent, Thread: tid, Child: child}
+
+	if d.Fork.FollowMode != FollowChild {
+		switch {
+		case !d.Fork.Detach && vfork:
+			// 父进程要等子进程 exec 或退出才能从 vfork 返回，停下来让用户先处理子进程
+			d.held = append(d.held, heldProcess{pid: child, tids: []int{child}, vfork: true})
+			d.vforkChild = child
+			d.tid = tid
+			if err := d.stopAll(); err != nil {
+				return false, err
+			}
+			event.Message = "child is held stopped, the parent cannot continue until it is detached"
+			d.emitStop(event)
+			return false, nil
+		case !d.Fork.Detach:
+			d.held = append(d.held, heldProcess{pid: child, tids: []int{child}})
+			event.Message = "child is held stopped"
+		case vfork:
+			// 子进程与父进程共享内存，断点先移除，在它 exec 或退出（PTRACE_EVENT_VFORK_DONE）后再写回
+			if err := d.liftBreakpoints(tid); err != nil {
+				return false, err
+			}
+			d.vforkLifted = true
+			syscall.PtraceDetach(child)
+			event.Message = "detached from child"
+		default:
+			if err := d.liftBreakpoints(child); err != nil {
+				return false, err
+			}
+			syscall.PtraceDetach(child)
+			event.Message = "detached from child"
+		}
+		d.emit(event)
+		return false, nil
+	}
+
+	// 跟随子进程：父进程的所有线程先暂停
+	d.tid = tid
+	if err := d.stopAll(); err != nil {
+		return false, err
+	}
+	old := heldProcess{pid: parent}
+	for t := range d.threads {
+		old.tids = append(old.tids, t)
+	}
+	switch {
+	case !d.Fork.Detach:
+		d.held = append(d.held, old)
+		event.Message = "following child, parent is held stopped"
+	case vfork:
+		// 共享内存中的断点子进程还要用，父进程等子进程 exec 或退出后再放开
+		d.vforkParent = &old
+		event.Message = "following child, parent is detached after the child execs"
+	default:
+		if err := d.releaseProcess(old); err != nil {
+			return false, err
+		}
+		event.Message = "following child, detached from parent"
+	}
+	if err := d.switchProcess(child); err != nil {
+		return false, err
+	}
+	// 尚未处理的 fork 事件属于已经放开的父进程
+	d.hit, d.record, d.pendingForks = nil, nil, nil
+	d.emit(event)
+	return true, nil
+}
+
+// returnToVforkParent 跟随的 vfork 子进程没有 exec 就退出时（例如 Go 运行时探测 pidfd
+// 支持时创建的子进程）重新跟随仍然暂停着的父进程，返回是否已切换
+func (d *Debugger) returnToVforkParent(pid int) bool {
+	p := d.vforkParent
+	if p == nil || pid != d.Process.Pid {
+		return false
+	}
+	process, err := os.FindProcess(p.pid)
+	if err != nil {
+		return false
+	}
+	d.vforkParent = nil
+	d.Process = process
+	d.tid = p.pid
+	d.threads = make(map[int]*thread)
+	for _, tid := range p.tids {
+		d.threads[tid] = &thread{tid: tid, stopped: true, started: true}
+	}
+	d.emit(Event{Kind: EventFork, PID: p.pid, Child: pid, Message: "child exited without exec, following parent again"})
+	return true
+}
+
+// handlePendingForks 处理 stopAll 或单步期间收到的 fork 事件
+func (d *Debugger) handlePendingForks() error {
+	for len(d.pendingForks) > 0 {
+		f := d.pendingForks[0]
+		d.pendingForks = d.pendingForks[1:]
+		if _, err := d.handleFork(f.tid, f.vfork); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DetachInferior 让 fork 后保持暂停的进程 pid 移除断点后脱离调试器继续运行
+func (d *Debugger) DetachInferior(pid int) error {
+	for i, p := range d.held {
+		if p.pid != pid {
+			continue
+		}
+		if err := d.releaseProcess(p); err != nil {
+			return err
+		}
+		d.held = append(d.held[:i], d.held[i+1:]...)
+		if pid == d.vforkChild {
+			// 断点从共享的内存中移除了，父进程从 vfork 返回（PTRACE_EVENT_VFORK_DONE）后再写回
+			d.vforkChild, d.vforkLifted = 0, true
+		}
+		return nil
+	}
+	return fmt.Errorf("process %d is not held by the debugger", pid)
+}
+
+// releaseProcess 从进程 p 的内存中移除断点，然后脱离它的所有线程
+func (d *Debugger) releaseProcess(p heldProcess) error {
+	if err := d.liftBreakpoints(p.pid); err != nil {
+		return err
+	}
+	for _, tid := range p.tids {
+		syscall.PtraceDetach(tid)
+	}
+	return nil
+}
+
+// liftBreakpoints 在进程 pid 的内存中恢复所有断点处的原始字节
+func (d *Debugger) liftBreakpoints(pid int) error {
+	for _, bp := range d.Breakpoints {
+		if bp.Enabled {
+			if err := writeProcessMemory(pid, bp.Address, bp.Original); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// vforkDone vfork 的子进程已经 exec 或退出，写回为它临时移除的断点
+func (d *Debugger) vforkDone(tid int) error {
+	if !d.vforkLifted {
+		return nil
+	}
+	d.vforkLifted = false
+	for _, bp := range d.Breakpoints {
+		if bp.Enabled {
+			if err := writeProcessMemory(tid, bp.Address, d.arch.BreakpointInstr()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handleExec 处理 PTRACE_EVENT_EXEC：进程换成了新的程序，其他线程都已消失。
+// 重新加载符号，断点按设置时的位置在新程序中重新解析，无法解析的被删除。
+func (d *Debugger) handleExec() error {
+	// 子进程 exec 后有了自己的内存，这时才能从 vfork 的父进程中移除断点并放开它
+	if d.vforkParent != nil {
+		if err := d.releaseProcess(*d.vforkParent); err != nil {
+			return err
+		}
+		d.vforkParent = nil
+	}
+	d.vforkLifted = false
+
+	pid := d.Process.Pid
+	d.tid = pid
+	d.threads = map[int]*thread{pid: {tid: pid, stopped: true, started: true}}
+	d.hit, d.record = nil, nil
+
+	event := Event{Kind: EventExec, PID: pid}
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		d.Executable = exe
+	}
+	event.Executable = d.Executable
+	d.Symbols = make(map[string]uint64)
+	d.DwarfData, d.index, d.frames, d.sections = nil, nil, nil, debugSections{}
+	if err := d.loadSymbols(); err != nil {
+		event.Message = fmt.Sprintf("no debugging symbols: %v", err)
+	}
+	d.emit(event)
+
+	old := d.SortedBreakpoints()
+	d.Breakpoints = make(map[uint64]*Breakpoint)
+	for _, bp := range old {
+		address, err := uint64(0), fmt.Errorf("address breakpoint")
+		if bp.Spec != "" && !strings.HasPrefix(bp.Spec, "0x") {
+			address, err = d.ResolveLocation(bp.Spec)
+		}
+		var orig []byte
+		if err == nil {
+			orig, err = d.insertBreakpoint(address)
+		}
+		if err != nil {
+			d.emit(Event{Kind: EventBreakpointRemoved, Breakpoint: bp.ID, Address: bp.Address})
+			continue
+		}
+		bp.Address, bp.Original = address, orig
+		d.Breakpoints[address] = bp
+	}
+	return d.installCatchpoints()
+}
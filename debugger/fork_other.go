@@ -0,0 +1,8 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package debugger
+
+func (d *Debugger) DetachInferior(pid int) error {
+	return unsupported("fork")
+}
@@ -0,0 +1,339 @@
+package debugger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// frameTable 解析后的 .debug_frame，按 DWARF CFI 规则计算每一帧的 CFA 和返回地址
+type frameTable struct {
+	fdes    []*fde // 按起始地址排序
+	order   binary.ByteOrder
+	ptrSize int
+}
+
+// cie Common Information Entry
+type cie struct {
+	codeAlign uint64
+	dataAlign int64
+	raReg     uint64
+	initial   []byte
+}
+
+// fde Frame Description Entry，覆盖 [begin, end) 的一个函数
+type fde struct {
+	cie   *cie
+	begin uint64
+	end   uint64
+	instr []byte
+}
+
+type ruleKind int
+
+const (
+	ruleUnspecified ruleKind = iota
+	ruleUndefined
+	ruleSameValue
+	ruleOffset    // 保存在 CFA+offset 处
+	ruleValOffset // 值就是 CFA+offset
+	ruleRegister  // 保存在另一个寄存器中
+)
+
+type regRule struct {
+	kind   ruleKind
+	offset int64
+	reg    uint64
+}
+
+// frameRules 某个 pc 处的 CFI 规则
+type frameRules struct {
+	cfaReg    uint64
+	cfaOffset int64
+	regs      map[uint64]regRule
+	raReg     uint64
+}
+
+// parseFrameTable 解析 .debug_frame 节
+func parseFrameTable(data []byte, order binary.ByteOrder, ptrSize int) (*frameTable, error) {
+	t := &frameTable{order: order, ptrSize: ptrSize}
+	cies := make(map[uint64]*cie)
+
+	for off := uint64(0); off+4 <= uint64(len(data)); {
+		start := off
+		length := uint64(order.Uint32(data[off:]))
+		off += 4
+		idSize := uint64(4)
+		if length == 0xffffffff {
+			if off+8 > uint64(len(data)) {
+				return nil, fmt.Errorf("truncated .debug_frame at 0x%x", start)
+			}
+			length = order.Uint64(data[off:])
+			off += 8
+			idSize = 8
+		}
+		if length == 0 {
+			continue
+		}
+		end := off + length
+		if end > uint64(len(data)) || length < idSize {
+			return nil, fmt.Errorf("truncated .debug_frame entry at 0x%x", start)
+		}
+		body := data[off:end]
+		off = end
+
+		var id uint64
+		if idSize == 4 {
+			id = uint64(order.Uint32(body))
+			if id == 0xffffffff {
+				id = ^uint64(0)
+			}
+		} else {
+			id = order.Uint64(body)
+		}
+		body = body[idSize:]
+
+		if id == ^uint64(0) {
+			c, err := parseCIE(body)
+			if err != nil {
+				return nil, fmt.Errorf("CIE at 0x%x: %v", start, err)
+			}
+			cies[start] = c
+			continue
+		}
+
+		c, ok := cies[id]
+		if !ok {
+			return nil, fmt.Errorf("FDE at 0x%x references unknown CIE 0x%x", start, id)
+		}
+		if len(body) < 2*ptrSize {
+			return nil, fmt.Errorf("truncated FDE at 0x%x", start)
+		}
+		begin := readAddr(body, order, ptrSize)
+		size := readAddr(body[ptrSize:], order, ptrSize)
+		t.fdes = append(t.fdes, &fde{cie: c, begin: begin, end: begin + size, instr: body[2*ptrSize:]})
+	}
+
+	sort.Slice(t.fdes, func(i, j int) bool { return t.fdes[i].begin < t.fdes[j].begin })
+	return t, nil
+}
+
+func parseCIE(body []byte) (*cie, error) {
+	if len(body) < 1 {
+		return nil, fmt.Errorf("empty CIE")
+	}
+	version := body[0]
+	body = body[1:]
+	// augmentation 字符串，Go 工具链输出空串
+	i := 0
+	for i < len(body) && body[i] != 0 {
+		i++
+	}
+	if i != 0 {
+		return nil, fmt.Errorf("unsupported CIE augmentation %q", body[:i])
+	}
+	body = body[i+1:]
+	if version >= 4 {
+		body = body[2:] // address_size, segment_selector_size
+	}
+	c := &cie{}
+	var n int
+	c.codeAlign, n = decodeULEB128(body)
+	body = body[n:]
+	c.dataAlign, n = decodeSLEB128(body)
+	body = body[n:]
+	if version == 1 {
+		c.raReg = uint64(body[0])
+		body = body[1:]
+	} else {
+		c.raReg, n = decodeULEB128(body)
+		body = body[n:]
+	}
+	c.initial = body
+	return c, nil
+}
+
+func readAddr(buf []byte, order binary.ByteOrder, ptrSize int) uint64 {
+	if ptrSize == 4 {
+		return uint64(order.Uint32(buf))
+	}
+	return order.Uint64(buf)
+}
+
+// lookup 查找覆盖 pc 的 FDE
+func (t *frameTable) lookup(pc uint64) *fde {
+	i := sort.Search(len(t.fdes), func(i int) bool { return t.fdes[i].begin > pc })
+	if i == 0 {
+		return nil
+	}
+	f := t.fdes[i-1]
+	if pc >= f.end {
+		return nil
+	}
+	return f
+}
+
+// rulesAt 执行 CIE 和 FDE 中的 CFI 指令，得到 pc 处的规则
+func (t *frameTable) rulesAt(pc uint64) (*frameRules, error) {
+	f := t.lookup(pc)
+	if f == nil {
+		return nil, fmt.Errorf("no frame information for 0x%x", pc)
+	}
+	rules := &frameRules{regs: make(map[uint64]regRule), raReg: f.cie.raReg}
+	if err := t.execute(rules, f.cie, f.cie.initial, f.begin, ^uint64(0), nil); err != nil {
+		return nil, err
+	}
+	initial := make(map[uint64]regRule, len(rules.regs))
+	for reg, rule := range rules.regs {
+		initial[reg] = rule
+	}
+	if err := t.execute(rules, f.cie, f.instr, f.begin, pc, initial); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// execute 解释 CFI 指令直到位置超过 pc
+func (t *frameTable) execute(rules *frameRules, c *cie, instr []byte, loc, pc uint64, initial map[uint64]regRule) error {
+	type state struct {
+		cfaReg    uint64
+		cfaOffset int64
+		regs      map[uint64]regRule
+	}
+	var stack []state
+
+	uleb := func() uint64 {
+		v, n := decodeULEB128(instr)
+		instr = instr[n:]
+		return v
+	}
+	sleb := func() int64 {
+		v, n := decodeSLEB128(instr)
+		instr = instr[n:]
+		return v
+	}
+	advance := func(delta uint64) bool {
+		loc += delta * c.codeAlign
+		return loc > pc
+	}
+
+	for len(instr) > 0 {
+		op := instr[0]
+		instr = instr[1:]
+
+		switch op & 0xc0 {
+		case 0x40: // DW_CFA_advance_loc
+			if advance(uint64(op & 0x3f)) {
+				return nil
+			}
+			continue
+		case 0x80: // DW_CFA_offset
+			rules.regs[uint64(op&0x3f)] = regRule{kind: ruleOffset, offset: int64(uleb()) * c.dataAlign}
+			continue
+		case 0xc0: // DW_CFA_restore
+			restoreRule(rules, initial, uint64(op&0x3f))
+			continue
+		}
+
+		switch op {
+		case 0x00: // DW_CFA_nop
+		case 0x01: // DW_CFA_set_loc
+			loc = readAddr(instr, t.order, t.ptrSize)
+			instr = instr[t.ptrSize:]
+			if loc > pc {
+				return nil
+			}
+		case 0x02: // DW_CFA_advance_loc1
+			delta := uint64(instr[0])
+			instr = instr[1:]
+			if advance(delta) {
+				return nil
+			}
+		case 0x03: // DW_CFA_advance_loc2
+			delta := uint64(t.order.Uint16(instr))
+			instr = instr[2:]
+			if advance(delta) {
+				return nil
+			}
+		case 0x04: // DW_CFA_advance_loc4
+			delta := uint64(t.order.Uint32(instr))
+			instr = instr[4:]
+			if advance(delta) {
+				return nil
+			}
+		case 0x05: // DW_CFA_offset_extended
+			reg := uleb()
+			rules.regs[reg] = regRule{kind: ruleOffset, offset: int64(uleb()) * c.dataAlign}
+		case 0x06: // DW_CFA_restore_extended
+			restoreRule(rules, initial, uleb())
+		case 0x07: // DW_CFA_undefined
+			rules.regs[uleb()] = regRule{kind: ruleUndefined}
+		case 0x08: // DW_CFA_same_value
+			rules.regs[uleb()] = regRule{kind: ruleSameValue}
+		case 0x09: // DW_CFA_register
+			reg := uleb()
+			rules.regs[reg] = regRule{kind: ruleRegister, reg: uleb()}
+		case 0x0a: // DW_CFA_remember_state
+			saved := make(map[uint64]regRule, len(rules.regs))
+			for reg, rule := range rules.regs {
+				saved[reg] = rule
+			}
+			stack = append(stack, state{rules.cfaReg, rules.cfaOffset, saved})
+		case 0x0b: // DW_CFA_restore_state
+			if len(stack) == 0 {
+				return fmt.Errorf("DW_CFA_restore_state with empty stack")
+			}
+			s := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			rules.cfaReg, rules.cfaOffset, rules.regs = s.cfaReg, s.cfaOffset, s.regs
+		case 0x0c: // DW_CFA_def_cfa
+			rules.cfaReg = uleb()
+			rules.cfaOffset = int64(uleb())
+		case 0x0d: // DW_CFA_def_cfa_register
+			rules.cfaReg = uleb()
+		case 0x0e: // DW_CFA_def_cfa_offset
+			rules.cfaOffset = int64(uleb())
+		case 0x11: // DW_CFA_offset_extended_sf
+			reg := uleb()
+			rules.regs[reg] = regRule{kind: ruleOffset, offset: sleb() * c.dataAlign}
+		case 0x12: // DW_CFA_def_cfa_sf
+			rules.cfaReg = uleb()
+			rules.cfaOffset = sleb() * c.dataAlign
+		case 0x13: // DW_CFA_def_cfa_offset_sf
+			rules.cfaOffset = sleb() * c.dataAlign
+		case 0x14: // DW_CFA_val_offset
+			reg := uleb()
+			rules.regs[reg] = regRule{kind: ruleValOffset, offset: int64(uleb()) * c.dataAlign}
+		case 0x15: // DW_CFA_val_offset_sf
+			reg := uleb()
+			rules.regs[reg] = regRule{kind: ruleValOffset, offset: sleb() * c.dataAlign}
+		case 0x2e: // DW_CFA_GNU_args_size
+			uleb()
+		default:
+			return fmt.Errorf("unsupported CFA opcode 0x%x", op)
+		}
+	}
+	return nil
+}
+
+func restoreRule(rules *frameRules, initial map[uint64]regRule, reg uint64) {
+	if rule, ok := initial[reg]; ok {
+		rules.regs[reg] = rule
+	} else {
+		delete(rules.regs, reg)
+	}
+}
+
+// decodeULEB128 解码无符号 LEB128，返回值和占用的字节数
+func decodeULEB128(buf []byte) (uint64, int) {
+	var result uint64
+	var shift uint
+	for i, b := range buf {
+		result |= uint64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return result, i + 1
+		}
+	}
+	return result, len(buf)
+}
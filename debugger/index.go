@@ -0,0 +1,410 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// symbolIndex 在加载时对 DWARF 建立索引，避免每次查询都线性扫描整个 .debug_info。
+// 只遍历编译单元的直接子节点（函数、全局变量），函数体内部和行号表按需解析。
+type symbolIndex struct {
+	data *dwarf.Data
+
+	funcs      []*funcEntry            // 按 lowpc 排序，用于 pc -> 函数
+	funcByName map[string]*funcEntry   // 函数名 -> 函数
+	globals    map[string]dwarf.Offset // 带包名（非 Go 编译单元为单元名）的变量名 -> DIE
+	units      []*compileUnit
+}
+
+// funcEntry 一个具有机器码的函数（DW_TAG_subprogram）
+type funcEntry struct {
+	Name   string
+	LowPC  uint64
+	HighPC uint64
+	Offset dwarf.Offset
+	unit   *compileUnit
+}
+
+// compileUnit 编译单元，行号表在第一次使用时解析
+type compileUnit struct {
+	Name  string
+	entry *dwarf.Entry
+
+	once  sync.Once
+	lines []dwarf.LineEntry
+	err   error
+}
+
+// buildSymbolIndex 扫描一遍编译单元的顶层条目并建立索引
+func buildSymbolIndex(data *dwarf.Data) (*symbolIndex, error) {
+	idx := &symbolIndex{
+		data:       data,
+		funcByName: make(map[string]*funcEntry),
+		globals:    make(map[string]dwarf.Offset),
+	}
+
+	// 内联函数的具体实例没有名字，需要通过 DW_AT_abstract_origin 找到抽象实例的名字
+	abstractNames := make(map[dwarf.Offset]string)
+	origins := make(map[*funcEntry]dwarf.Offset)
+
+	var unit *compileUnit
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, fmt.Errorf("read DWARF: %v", err)
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			unit = &compileUnit{Name: name, entry: entry}
+			idx.units = append(idx.units, unit)
+			continue
+
+		case dwarf.TagSubprogram:
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			if entry.Val(dwarf.AttrInline) != nil {
+				abstractNames[entry.Offset] = name
+				break
+			}
+			lowpc, highpc, ok := entryPCRange(entry)
+			if !ok {
+				break
+			}
+			fn := &funcEntry{Name: name, LowPC: lowpc, HighPC: highpc, Offset: entry.Offset, unit: unit}
+			if origin, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); ok && name == "" {
+				origins[fn] = origin
+			}
+			idx.funcs = append(idx.funcs, fn)
+
+		case dwarf.TagVariable:
+			if name, ok := entry.Val(dwarf.AttrName).(string); ok {
+				idx.globals[qualifiedName(unit, name)] = entry.Offset
+			}
+		}
+
+		// 函数体（局部变量、词法块等）留到真正查询时再读
+		if entry.Children {
+			reader.SkipChildren()
+		}
+	}
+
+	for fn, origin := range origins {
+		fn.Name = abstractNames[origin]
+	}
+
+	sort.Slice(idx.funcs, func(i, j int) bool { return idx.funcs[i].LowPC < idx.funcs[j].LowPC })
+	for _, fn := range idx.funcs {
+		if fn.Name == "" {
+			continue
+		}
+		if _, exists := idx.funcByName[fn.Name]; !exists {
+			idx.funcByName[fn.Name] = fn
+		}
+	}
+
+	return idx, nil
+}
+
+// qualifiedName Go 的包级变量名已经带有包名（main.x），
+// C 等其他编译单元中的名字加上单元名，避免不同文件中的同名静态变量互相覆盖
+func qualifiedName(unit *compileUnit, name string) string {
+	if strings.Contains(name, ".") || unit == nil {
+		return name
+	}
+	return unit.Name + "." + name
+}
+
+// entryPCRange 读取条目的 [lowpc, highpc)，DWARF 4+ 中 highpc 可能是相对 lowpc 的偏移
+func entryPCRange(entry *dwarf.Entry) (uint64, uint64, bool) {
+	lowpc, ok := entry.Val(dwarf.AttrLowpc).(uint64)
+	if !ok {
+		return 0, 0, false
+	}
+	field := entry.AttrField(dwarf.AttrHighpc)
+	if field == nil {
+		return 0, 0, false
+	}
+	switch v := field.Val.(type) {
+	case uint64:
+		return lowpc, v, true
+	case int64:
+		return lowpc, lowpc + uint64(v), true
+	}
+	return 0, 0, false
+}
+
+// funcByPC 二分查找包含 pc 的函数
+func (idx *symbolIndex) funcByPC(pc uint64) *funcEntry {
+	i := sort.Search(len(idx.funcs), func(i int) bool { return idx.funcs[i].LowPC > pc })
+	if i == 0 {
+		return nil
+	}
+	fn := idx.funcs[i-1]
+	if pc >= fn.HighPC {
+		return nil
+	}
+	return fn
+}
+
+// lookupFunc 按名字查找函数
+func (idx *symbolIndex) lookupFunc(name string) *funcEntry {
+	return idx.funcByName[name]
+}
+
+// lookupGlobal 按名字查找包级变量的 DIE。不带包名的名字只在唯一匹配时有效
+func (idx *symbolIndex) lookupGlobal(name string) (*dwarf.Entry, error) {
+	off, ok := idx.globals[name]
+	if !ok {
+		var matches []string
+		for qualified := range idx.globals {
+			if strings.HasSuffix(qualified, "."+name) {
+				matches = append(matches, qualified)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("variable '%s' not found", name)
+		case 1:
+			off = idx.globals[matches[0]]
+		default:
+			sort.Strings(matches)
+			return nil, fmt.Errorf("variable '%s' is ambiguous: %s", name, strings.Join(matches, ", "))
+		}
+	}
+	return idx.entryAt(off)
+}
+
+// entryAt 读取指定偏移处的 DIE
+func (idx *symbolIndex) entryAt(off dwarf.Offset) (*dwarf.Entry, error) {
+	reader := idx.data.Reader()
+	reader.Seek(off)
+	entry, err := reader.Next()
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("no DWARF entry at offset 0x%x", off)
+	}
+	return entry, nil
+}
+
+// lineTable 返回编译单元按地址排序的行号表，第一次调用时解析
+func (cu *compileUnit) lineTable(data *dwarf.Data) ([]dwarf.LineEntry, error) {
+	cu.once.Do(func() {
+		lr, err := data.LineReader(cu.entry)
+		if err != nil {
+			cu.err = err
+			return
+		}
+		if lr == nil {
+			return
+		}
+		var le dwarf.LineEntry
+		for {
+			if err := lr.Next(&le); err != nil {
+				break
+			}
+			cu.lines = append(cu.lines, le)
+		}
+		sortLineTable(cu.lines)
+	})
+	return cu.lines, cu.err
+}
+
+// sortLineTable 按地址排序行号表。一个序列的结束行与下一个序列的第一行地址相同时，结束行排在前面
+func sortLineTable(lines []dwarf.LineEntry) {
+	sort.SliceStable(lines, func(i, j int) bool {
+		a, b := lines[i], lines[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return a.EndSequence && !b.EndSequence
+	})
+}
+
+// pcToLine 将 pc 映射到源文件和行号
+func (idx *symbolIndex) pcToLine(pc uint64) (string, int, error) {
+	fn := idx.funcByPC(pc)
+	if fn == nil || fn.unit == nil {
+		return "", 0, fmt.Errorf("no line information for 0x%x", pc)
+	}
+	lines, err := fn.unit.lineTable(idx.data)
+	if err != nil {
+		return "", 0, err
+	}
+	// 向前找到覆盖 pc 的行，遇到序列结束行说明 pc 落在两个序列之间的空隙中
+	i := sort.Search(len(lines), func(i int) bool { return lines[i].Address > pc })
+	for ; i > 0; i-- {
+		le := lines[i-1]
+		if le.EndSequence {
+			break
+		}
+		if le.File != nil {
+			return le.File.Name, le.Line, nil
+		}
+	}
+	return "", 0, fmt.Errorf("no line information for 0x%x", pc)
+}
+
+// prologueEnd 返回函数序言（栈检查和建立栈帧）之后的地址。栈增长时函数会从入口重新执行，
+// 设置在序言之后的断点不会因此命中两次。行号表中没有 prologue_end 标记时返回函数入口。
+func (idx *symbolIndex) prologueEnd(fn *funcEntry) uint64 {
+	if fn.unit == nil {
+		return fn.LowPC
+	}
+	lines, err := fn.unit.lineTable(idx.data)
+	if err != nil {
+		return fn.LowPC
+	}
+	i := sort.Search(len(lines), func(i int) bool { return lines[i].Address >= fn.LowPC })
+	for ; i < len(lines) && lines[i].Address < fn.HighPC; i++ {
+		if lines[i].PrologueEnd {
+			return lines[i].Address
+		}
+	}
+	return fn.LowPC
+}
+
+// lineToPC 查找 file:line 对应的第一条语句地址，file 可以是路径后缀
+func (idx *symbolIndex) lineToPC(file string, line int) (uint64, error) {
+	for _, cu := range idx.units {
+		lines, err := cu.lineTable(idx.data)
+		if err != nil {
+			continue
+		}
+		var best uint64
+		for _, le := range lines {
+			if le.Line != line || !le.IsStmt || le.File == nil || !matchFile(le.File.Name, file) {
+				continue
+			}
+			if best == 0 || le.Address < best {
+				best = le.Address
+			}
+		}
+		if best != 0 {
+			return best, nil
+		}
+	}
+	return 0, fmt.Errorf("no code at %s:%d", file, line)
+}
+
+// matchFile 判断 DWARF 中的完整路径是否以 file 结尾（按路径分量匹配）
+func matchFile(full, file string) bool {
+	if full == file {
+		return true
+	}
+	return strings.HasSuffix(full, "/"+strings.TrimPrefix(file, "./"))
+}
+
+// sourceFiles 返回行号表中出现的所有源文件，会解析全部编译单元的行号表
+func (idx *symbolIndex) sourceFiles() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, cu := range idx.units {
+		lines, err := cu.lineTable(idx.data)
+		if err != nil {
+			continue
+		}
+		for _, le := range lines {
+			if le.File != nil && !seen[le.File.Name] {
+				seen[le.File.Name] = true
+				files = append(files, le.File.Name)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// localNames 返回在 pc 处可见的局部变量和参数名
+func (idx *symbolIndex) localNames(fn *funcEntry, pc uint64) []string {
+	var names []string
+	for _, entry := range idx.scopeVariables(fn, pc) {
+		names = append(names, entry.Val(dwarf.AttrName).(string))
+	}
+	return names
+}
+
+// scopeVariables 返回在 pc 处可见的局部变量和参数 DIE，按声明顺序排列。
+// 不包含 pc 的词法块被跳过，声明在当前行之后的变量还不可见，内层块中的同名变量遮蔽外层的。
+func (idx *symbolIndex) scopeVariables(fn *funcEntry, pc uint64) []*dwarf.Entry {
+	type scoped struct {
+		entry *dwarf.Entry
+		depth int
+	}
+	_, line, lineErr := idx.pcToLine(pc)
+
+	var vars []scoped
+	reader := idx.data.Reader()
+	reader.Seek(fn.Offset)
+	reader.Next() // 函数本身
+	for depth := 1; depth > 0; {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		if entry.Tag == 0 {
+			depth--
+			continue
+		}
+		if entry.Children {
+			// 只进入包含 pc 的词法块，内联函数等其他子树不属于本函数的作用域
+			if entry.Tag != dwarf.TagLexDwarfBlock || !idx.blockContains(entry, pc) {
+				reader.SkipChildren()
+				continue
+			}
+			depth++
+			continue
+		}
+		if entry.Tag != dwarf.TagVariable && entry.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		if _, ok := entry.Val(dwarf.AttrName).(string); !ok {
+			continue
+		}
+		if declLine, ok := entry.Val(dwarf.AttrDeclLine).(int64); ok && entry.Tag == dwarf.TagVariable && lineErr == nil && int(declLine) > line {
+			continue
+		}
+		vars = append(vars, scoped{entry: entry, depth: depth})
+	}
+
+	// 同名变量只保留最内层的
+	inner := make(map[string]int)
+	for _, v := range vars {
+		name := v.entry.Val(dwarf.AttrName).(string)
+		if d, ok := inner[name]; !ok || v.depth > d {
+			inner[name] = v.depth
+		}
+	}
+	var entries []*dwarf.Entry
+	for _, v := range vars {
+		name := v.entry.Val(dwarf.AttrName).(string)
+		if inner[name] == v.depth {
+			entries = append(entries, v.entry)
+			inner[name] = -1 // 同一块中重复出现时只取第一个
+		}
+	}
+	return entries
+}
+
+// blockContains 判断词法块的地址范围（lowpc/highpc 或 DW_AT_ranges）是否包含 pc
+func (idx *symbolIndex) blockContains(entry *dwarf.Entry, pc uint64) bool {
+	ranges, err := idx.data.Ranges(entry)
+	if err != nil {
+		return false
+	}
+	for _, r := range ranges {
+		if pc >= r[0] && pc < r[1] {
+			return true
+		}
+	}
+	return false
+}
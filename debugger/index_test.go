@@ -0,0 +1,242 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// buildTestProgram 把 testprog 复制到临时目录中单独的模块里，以调试参数编译，返回可执行文件路径。
+// 自动保存的断点写在这个模块的 .tz-gin 目录下，不会影响仓库
+func buildTestProgram(tb testing.TB) string {
+	tb.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		tb.Skip("go toolchain not available")
+	}
+	dir := tb.TempDir()
+	src, err := os.ReadFile("../testprog/main.go")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	files := map[string]string{"main.go": string(src), "go.mod": "module testprog\n\ngo 1.21\n"}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	exe := filepath.Join(dir, "testprog")
+	cmd := exec.Command("go", "build", "-gcflags=all=-N -l", "-o", exe, ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("build testprog: %v\n%s", err, out)
+	}
+	return exe
+}
+
+// loadTestDWARF 读取 testprog 的 DWARF
+func loadTestDWARF(b *testing.B) *dwarf.Data {
+	b.Helper()
+	f, err := elf.Open(buildTestProgram(b))
+	if err != nil {
+		b.Skipf("not an ELF binary: %v", err)
+	}
+	defer f.Close()
+	data, err := f.DWARF()
+	if err != nil {
+		b.Fatalf("no DWARF data: %v", err)
+	}
+	return data
+}
+
+// 索引建立之前的做法：每次查询都从头扫描整个 .debug_info
+
+func scanFunction(data *dwarf.Data, name string) (uint64, bool) {
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			return 0, false
+		}
+		if entry.Tag == dwarf.TagSubprogram {
+			if n, _ := entry.Val(dwarf.AttrName).(string); n == name {
+				if lowpc, ok := entry.Val(dwarf.AttrLowpc).(uint64); ok {
+					return lowpc, true
+				}
+			}
+		}
+	}
+}
+
+func scanGlobal(data *dwarf.Data, name string) (*dwarf.Entry, bool) {
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			return nil, false
+		}
+		if entry.Tag == dwarf.TagVariable {
+			if n, _ := entry.Val(dwarf.AttrName).(string); n == name {
+				return entry, true
+			}
+		}
+		if entry.Tag == dwarf.TagSubprogram && entry.Children {
+			reader.SkipChildren()
+		}
+	}
+}
+
+func scanPCToLine(data *dwarf.Data, pc uint64) (int, bool) {
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			return 0, false
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			continue
+		}
+		lr, err := data.LineReader(entry)
+		if err != nil || lr == nil {
+			continue
+		}
+		var le, prev dwarf.LineEntry
+		havePrev := false
+		for lr.Next(&le) == nil {
+			if havePrev && !prev.EndSequence && prev.Address <= pc && pc < le.Address {
+				return prev.Line, true
+			}
+			prev, havePrev = le, true
+		}
+		reader.SkipChildren()
+	}
+}
+
+// benchTargets 选取 main 包的函数和按名字排在最后的包级变量作为查询目标
+func benchTargets(b *testing.B, idx *symbolIndex) (*funcEntry, string) {
+	b.Helper()
+	fn := idx.lookupFunc("main.fibonacci")
+	if fn == nil {
+		b.Fatal("benchmark function not indexed")
+	}
+	var globals []string
+	for name := range idx.globals {
+		globals = append(globals, name)
+	}
+	if len(globals) == 0 {
+		b.Fatal("no globals indexed")
+	}
+	sort.Strings(globals)
+	return fn, globals[len(globals)-1]
+}
+
+func BenchmarkLookup(b *testing.B) {
+	data := loadTestDWARF(b)
+	idx, err := buildSymbolIndex(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	fn, global := benchTargets(b, idx)
+	pc := idx.prologueEnd(fn)
+
+	b.Run("BuildIndex", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := buildSymbolIndex(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Function/Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if idx.lookupFunc(fn.Name) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("Function/Scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, ok := scanFunction(data, fn.Name); !ok {
+				b.Fatal("not found")
+			}
+		}
+	})
+
+	b.Run("Global/Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := idx.lookupGlobal(global); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Global/Scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, ok := scanGlobal(data, global); !ok {
+				b.Fatal("not found")
+			}
+		}
+	})
+
+	b.Run("PCToLine/Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, err := idx.pcToLine(pc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("PCToLine/Scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, ok := scanPCToLine(data, pc); !ok {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
+
+func TestPCToLineSequenceGap(t *testing.T) {
+	file := &dwarf.LineFile{Name: "main.go"}
+	// 两个序列：0x100-0x120 和 0x120-0x140，之后 0x140-0x180 是没有行号的空隙，
+	// 第二个序列写在前面，排序后结束行要排在同地址的起始行之前
+	lines := []dwarf.LineEntry{
+		{Address: 0x120, File: file, Line: 20},
+		{Address: 0x130, File: file, Line: 21},
+		{Address: 0x140, EndSequence: true},
+		{Address: 0x100, File: file, Line: 10},
+		{Address: 0x110, File: file, Line: 11},
+		{Address: 0x120, EndSequence: true},
+		{Address: 0x180, File: file, Line: 30},
+		{Address: 0x190, EndSequence: true},
+	}
+	sortLineTable(lines)
+	unit := &compileUnit{lines: lines}
+	unit.once.Do(func() {})
+	idx := &symbolIndex{funcs: []*funcEntry{{Name: "main.f", LowPC: 0x100, HighPC: 0x190, unit: unit}}}
+
+	tests := []struct {
+		pc   uint64
+		line int
+	}{
+		{pc: 0x100, line: 10},
+		{pc: 0x11f, line: 11},
+		{pc: 0x120, line: 20},
+		{pc: 0x13f, line: 21},
+		{pc: 0x140, line: 0},
+		{pc: 0x17f, line: 0},
+		{pc: 0x180, line: 30},
+	}
+	for _, tt := range tests {
+		_, line, err := idx.pcToLine(tt.pc)
+		if tt.line == 0 {
+			if err == nil {
+				t.Errorf("pcToLine(0x%x) = line %d, want no line information", tt.pc, line)
+			}
+			continue
+		}
+		if err != nil || line != tt.line {
+			t.Errorf("pcToLine(0x%x) = %d, %v, want %d", tt.pc, line, err, tt.line)
+		}
+	}
+}
@@ -7,13 +7,46 @@ import (
 
 type DebuggerInterface interface {
 	Launch(args []string) error
-	Continue() error
+	Continue() (StopReason, error)
+	Step() (StopReason, error)
 	SetBreakpoint(address uint64) error
 	RemoveBreakpoint(address uint64) error
 	ReadMemory(address uint64, size int) ([]byte, error)
 	WriteMemory(address uint64, data []byte) error
 	GetRegisters() (map[string]uint64, error)
 	Kill() error
+	Capabilities() Capabilities
+}
+
+// Capabilities 描述后端真正支持的操作，REPL 据此隐藏或标记不可用的命令
+type Capabilities struct {
+	Launch      bool
+	Continue    bool
+	Step        bool
+	Breakpoints bool
+	Registers   bool
+	ReadMemory  bool
+	WriteMemory bool
+	StackTrace  bool
+	Detach      bool
+	Kill        bool
+	Call        bool
+	Checkpoint  bool
+	Record      bool
+	Catch       bool
+}
+
+// ErrUnsupported 当前平台或后端不支持该操作
+type ErrUnsupported struct {
+	Op string
+}
+
+func (e *ErrUnsupported) Error() string {
+	return fmt.Sprintf("%s is not supported on %s/%s", e.Op, runtime.GOOS, runtime.GOARCH)
+}
+
+func unsupported(op string) error {
+	return &ErrUnsupported{Op: op}
 }
 
 func NewPlatformDebugger(executable string) (DebuggerInterface, error) {
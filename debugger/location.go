@@ -0,0 +1,474 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"fmt"
+)
+
+// registerValueBase 寄存器中的变量映射到的伪地址，amd64/arm64 用户态不会使用这段地址
+const registerValueBase = 1 << 63
+
+// location 变量的位置：内存地址，或者由寄存器（可能经 DW_OP_piece 组合）得到的值
+type location struct {
+	addr   uint64
+	data   []byte     // 非 nil 时变量不在内存中，值就是 data
+	pieces []locPiece // data 的各部分来自哪里，写回变量时使用
+}
+
+// locPiece 不在内存中的值的一部分：寄存器、内存，或者不可写的计算结果（两者都没有）
+type locPiece struct {
+	size  uint64
+	reg   uint64
+	inReg bool
+	addr  uint64
+}
+
+// debugSections 解析位置列表需要的原始节，DWARF 5 使用 .debug_loclists 和 .debug_addr，
+// 更早的版本使用 .debug_loc
+type debugSections struct {
+	loclists []byte
+	loc      []byte
+	addr     []byte
+}
+
+// variableLocation 计算变量 DIE 在帧 frame 中的位置
+func (d *Debugger) variableLocation(entry *dwarf.Entry, fn *funcEntry, frame *Frame) (location, error) {
+	field := entry.AttrField(dwarf.AttrLocation)
+	if field == nil {
+		return location{}, fmt.Errorf("no location (optimized out)")
+	}
+	var expr []byte
+	switch field.Class {
+	case dwarf.ClassExprLoc, dwarf.ClassBlock:
+		expr, _ = field.Val.([]byte)
+	case dwarf.ClassLocListPtr, dwarf.ClassLocList:
+		if frame == nil || fn == nil {
+			return location{}, fmt.Errorf("location list without a frame")
+		}
+		off, _ := field.Val.(int64)
+		var err error
+		if expr, err = d.locListExpr(fn.unit, uint64(off), frame.lookupPC()); err != nil {
+			return location{}, err
+		}
+	default:
+		return location{}, fmt.Errorf("unsupported location class %v", field.Class)
+	}
+	if len(expr) == 0 {
+		return location{}, fmt.Errorf("optimized out")
+	}
+
+	var frameBase uint64
+	if frame != nil && fn != nil {
+		base, err := d.frameBase(fn, frame)
+		if err != nil {
+			return location{}, err
+		}
+		frameBase = base
+	}
+	return d.evalLocation(expr, frame, frameBase)
+}
+
+// frameBase 计算函数的 DW_AT_frame_base，Go 编译器总是使用 DW_OP_call_frame_cfa
+func (d *Debugger) frameBase(fn *funcEntry, frame *Frame) (uint64, error) {
+	entry, err := d.index.entryAt(fn.Offset)
+	if err != nil {
+		return 0, err
+	}
+	expr, ok := entry.Val(dwarf.AttrFrameBase).([]byte)
+	if !ok || len(expr) == 1 && expr[0] == 0x9c {
+		return frame.CFA, nil
+	}
+	loc, err := d.evalLocation(expr, frame, 0)
+	if err != nil {
+		return 0, fmt.Errorf("frame base: %v", err)
+	}
+	if loc.data != nil {
+		return littleEndian(loc.data), nil
+	}
+	return loc.addr, nil
+}
+
+// locListExpr 在编译单元 unit 的位置列表中查找覆盖 pc 的表达式
+func (d *Debugger) locListExpr(unit *compileUnit, off, pc uint64) ([]byte, error) {
+	if unit == nil {
+		return nil, fmt.Errorf("location list without a compile unit")
+	}
+	base, _ := unit.entry.Val(dwarf.AttrLowpc).(uint64)
+	if d.sections.loclists != nil {
+		return d.locListExpr5(unit, off, base, pc)
+	}
+	return d.locListExpr4(off, base, pc)
+}
+
+// locListExpr5 解析 DWARF 5 的 .debug_loclists
+func (d *Debugger) locListExpr5(unit *compileUnit, off, base, pc uint64) ([]byte, error) {
+	buf := d.sections.loclists
+	if off >= uint64(len(buf)) {
+		return nil, fmt.Errorf("location list offset 0x%x out of range", off)
+	}
+	buf = buf[off:]
+	addrBase, _ := unit.entry.Val(dwarf.AttrAddrBase).(int64)
+	addrx := func(i uint64) (uint64, error) {
+		at := uint64(addrBase) + i*8
+		if at+8 > uint64(len(d.sections.addr)) {
+			return 0, fmt.Errorf("address index %d out of range", i)
+		}
+		return binary.LittleEndian.Uint64(d.sections.addr[at:]), nil
+	}
+	uleb := func() uint64 {
+		v, n := decodeULEB128(buf)
+		buf = buf[n:]
+		return v
+	}
+	addr := func() uint64 {
+		if len(buf) < 8 {
+			buf = nil
+			return 0
+		}
+		v := binary.LittleEndian.Uint64(buf)
+		buf = buf[8:]
+		return v
+	}
+
+	for len(buf) > 0 {
+		kind := buf[0]
+		buf = buf[1:]
+		var lo, hi uint64
+		var err error
+		switch kind {
+		case 0x00: // DW_LLE_end_of_list
+			return nil, fmt.Errorf("no location at pc 0x%x", pc)
+		case 0x01: // DW_LLE_base_addressx
+			if base, err = addrx(uleb()); err != nil {
+				return nil, err
+			}
+			continue
+		case 0x02: // DW_LLE_startx_endx
+			if lo, err = addrx(uleb()); err == nil {
+				hi, err = addrx(uleb())
+			}
+		case 0x03: // DW_LLE_startx_length
+			if lo, err = addrx(uleb()); err == nil {
+				hi = lo + uleb()
+			}
+		case 0x04: // DW_LLE_offset_pair
+			lo = base + uleb()
+			hi = base + uleb()
+		case 0x05: // DW_LLE_default_location
+			lo, hi = 0, ^uint64(0)
+		case 0x06: // DW_LLE_base_address
+			base = addr()
+			continue
+		case 0x07: // DW_LLE_start_end
+			lo = addr()
+			hi = addr()
+		case 0x08: // DW_LLE_start_length
+			lo = addr()
+			hi = lo + uleb()
+		default:
+			return nil, fmt.Errorf("unsupported location list entry 0x%x", kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		n := uleb()
+		if n > uint64(len(buf)) {
+			break
+		}
+		expr := buf[:n]
+		buf = buf[n:]
+		if pc >= lo && pc < hi {
+			return expr, nil
+		}
+	}
+	return nil, fmt.Errorf("no location at pc 0x%x", pc)
+}
+
+// locListExpr4 解析 DWARF 4 及以前的 .debug_loc：地址对、2 字节长度和表达式
+func (d *Debugger) locListExpr4(off, base, pc uint64) ([]byte, error) {
+	buf := d.sections.loc
+	if off >= uint64(len(buf)) {
+		return nil, fmt.Errorf("location list offset 0x%x out of range", off)
+	}
+	buf = buf[off:]
+	for len(buf) >= 16 {
+		lo := binary.LittleEndian.Uint64(buf)
+		hi := binary.LittleEndian.Uint64(buf[8:])
+		buf = buf[16:]
+		switch {
+		case lo == 0 && hi == 0:
+			return nil, fmt.Errorf("no location at pc 0x%x", pc)
+		case lo == ^uint64(0):
+			base = hi
+			continue
+		}
+		if len(buf) < 2 {
+			break
+		}
+		n := int(binary.LittleEndian.Uint16(buf))
+		buf = buf[2:]
+		if n > len(buf) {
+			break
+		}
+		if pc >= base+lo && pc < base+hi {
+			return buf[:n], nil
+		}
+		buf = buf[n:]
+	}
+	return nil, fmt.Errorf("no location at pc 0x%x", pc)
+}
+
+// evalLocation 执行 DWARF 位置表达式。调用者帧中只有 CFI 恢复出的栈指针是可信的，
+// 其余寄存器在调用过程中已被覆盖（Go 没有被调用者保存的寄存器）。
+func (d *Debugger) evalLocation(expr []byte, frame *Frame, frameBase uint64) (location, error) {
+	var stack []uint64
+	var pieces []byte
+	var inReg, isValue, composite bool
+	var regVal, regNum uint64
+	var parts []locPiece
+
+	pop := func() (uint64, error) {
+		if len(stack) == 0 {
+			return 0, fmt.Errorf("DWARF expression stack underflow")
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v, nil
+	}
+	reg := func(n uint64) (uint64, error) {
+		if frame == nil {
+			return 0, fmt.Errorf("register location without a frame")
+		}
+		if frame.outer && n != d.arch.DwarfSPReg() {
+			return 0, fmt.Errorf("value not available in this frame (held in a register)")
+		}
+		v, ok := frame.regs[n]
+		if !ok {
+			return 0, fmt.Errorf("register %d not available", n)
+		}
+		return v, nil
+	}
+
+	for len(expr) > 0 {
+		op := expr[0]
+		expr = expr[1:]
+		switch {
+		case op == 0x03: // DW_OP_addr
+			if len(expr) < 8 {
+				return location{}, fmt.Errorf("truncated DW_OP_addr")
+			}
+			stack = append(stack, binary.LittleEndian.Uint64(expr))
+			expr = expr[8:]
+		case op == 0x06: // DW_OP_deref
+			addr, err := pop()
+			if err != nil {
+				return location{}, err
+			}
+			data, err := d.ReadMemory(addr, 8)
+			if err != nil {
+				return location{}, err
+			}
+			stack = append(stack, binary.LittleEndian.Uint64(data))
+		case op >= 0x08 && op <= 0x0f: // DW_OP_const1u .. DW_OP_const8s
+			size := 1 << ((op - 0x08) / 2)
+			if len(expr) < size {
+				return location{}, fmt.Errorf("truncated constant")
+			}
+			var v uint64
+			if (op-0x08)%2 == 1 {
+				v = uint64(signExtend(expr[:size]))
+			} else {
+				v = littleEndian(expr[:size])
+			}
+			stack = append(stack, v)
+			expr = expr[size:]
+		case op == 0x10: // DW_OP_constu
+			v, n := decodeULEB128(expr)
+			stack, expr = append(stack, v), expr[n:]
+		case op == 0x11: // DW_OP_consts
+			v, n := decodeSLEB128(expr)
+			stack, expr = append(stack, uint64(v)), expr[n:]
+		case op == 0x12: // DW_OP_dup
+			if len(stack) == 0 {
+				return location{}, fmt.Errorf("DWARF expression stack underflow")
+			}
+			stack = append(stack, stack[len(stack)-1])
+		case op == 0x1c || op == 0x22: // DW_OP_minus, DW_OP_plus
+			b, err := pop()
+			if err != nil {
+				return location{}, err
+			}
+			a, err := pop()
+			if err != nil {
+				return location{}, err
+			}
+			if op == 0x1c {
+				stack = append(stack, a-b)
+			} else {
+				stack = append(stack, a+b)
+			}
+		case op == 0x23: // DW_OP_plus_uconst
+			a, err := pop()
+			if err != nil {
+				return location{}, err
+			}
+			v, n := decodeULEB128(expr)
+			stack, expr = append(stack, a+v), expr[n:]
+		case op >= 0x30 && op <= 0x4f: // DW_OP_lit0..31
+			stack = append(stack, uint64(op-0x30))
+		case op >= 0x50 && op <= 0x6f, op == 0x90: // DW_OP_reg0..31, DW_OP_regx
+			n := uint64(op - 0x50)
+			if op == 0x90 {
+				var w int
+				n, w = decodeULEB128(expr)
+				expr = expr[w:]
+			}
+			v, err := reg(n)
+			if err != nil {
+				return location{}, err
+			}
+			regVal, regNum, inReg = v, n, true
+		case op >= 0x70 && op <= 0x8f, op == 0x92: // DW_OP_breg0..31, DW_OP_bregx
+			n := uint64(op - 0x70)
+			if op == 0x92 {
+				var w int
+				n, w = decodeULEB128(expr)
+				expr = expr[w:]
+			}
+			off, w := decodeSLEB128(expr)
+			expr = expr[w:]
+			v, err := reg(n)
+			if err != nil {
+				return location{}, err
+			}
+			stack = append(stack, v+uint64(off))
+		case op == 0x91: // DW_OP_fbreg
+			if frameBase == 0 {
+				return location{}, fmt.Errorf("local variable without a frame")
+			}
+			off, w := decodeSLEB128(expr)
+			stack, expr = append(stack, frameBase+uint64(off)), expr[w:]
+		case op == 0x93: // DW_OP_piece
+			size, w := decodeULEB128(expr)
+			expr = expr[w:]
+			piece := make([]byte, size)
+			part := locPiece{size: size}
+			switch {
+			case inReg:
+				var buf [8]byte
+				binary.LittleEndian.PutUint64(buf[:], regVal)
+				copy(piece, buf[:])
+				part.reg, part.inReg = regNum, true
+			case isValue && len(stack) > 0:
+				var buf [8]byte
+				binary.LittleEndian.PutUint64(buf[:], stack[len(stack)-1])
+				copy(piece, buf[:])
+			case len(stack) > 0:
+				data, err := d.ReadMemory(stack[len(stack)-1], int(size))
+				if err != nil {
+					return location{}, err
+				}
+				copy(piece, data)
+				part.addr = stack[len(stack)-1]
+			}
+			// 空栈表示这一部分被优化掉了，按零处理
+			pieces = append(pieces, piece...)
+			parts = append(parts, part)
+			stack, inReg, isValue, composite = stack[:0], false, false, true
+		case op == 0x96: // DW_OP_nop
+		case op == 0x9c: // DW_OP_call_frame_cfa
+			if frame == nil || frame.CFA == 0 {
+				return location{}, fmt.Errorf("no CFA for this frame")
+			}
+			stack = append(stack, frame.CFA)
+		case op == 0x9f: // DW_OP_stack_value
+			isValue = true
+		default:
+			return location{}, fmt.Errorf("unsupported DWARF location op 0x%x", op)
+		}
+	}
+
+	switch {
+	case composite:
+		return location{data: pieces, pieces: parts}, nil
+	case inReg:
+		data := make([]byte, 8)
+		binary.LittleEndian.PutUint64(data, regVal)
+		return location{data: data, pieces: []locPiece{{size: 8, reg: regNum, inReg: true}}}, nil
+	}
+	v, err := pop()
+	if err != nil {
+		return location{}, err
+	}
+	if isValue {
+		data := make([]byte, 8)
+		binary.LittleEndian.PutUint64(data, v)
+		return location{data: data}, nil
+	}
+	return location{addr: v}, nil
+}
+
+// writeLocation 把 data 写回变量的位置。寄存器中的部分写入当前线程的寄存器，
+// 只能在最内层的帧中修改，调用者帧的寄存器已被覆盖
+func (d *Debugger) writeLocation(loc location, frame *Frame, data []byte) error {
+	if loc.data == nil {
+		return d.WriteMemory(loc.addr, data)
+	}
+	if len(loc.pieces) == 0 {
+		return fmt.Errorf("value is computed and cannot be modified")
+	}
+	var off uint64
+	for _, p := range loc.pieces {
+		if off >= uint64(len(data)) {
+			break
+		}
+		part := data[off:]
+		if uint64(len(part)) > p.size {
+			part = part[:p.size]
+		}
+		off += p.size
+		switch {
+		case p.inReg:
+			if err := d.writeRegister(p.reg, part, frame); err != nil {
+				return err
+			}
+		case p.addr != 0:
+			if err := d.WriteMemory(p.addr, part); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("part of the value is optimized out and cannot be modified")
+		}
+	}
+	return nil
+}
+
+// writeRegister 用 data 替换 DWARF 编号为 n 的寄存器的低位字节，并更新帧中缓存的寄存器
+func (d *Debugger) writeRegister(n uint64, data []byte, frame *Frame) error {
+	if frame == nil || frame.outer {
+		return fmt.Errorf("cannot modify a register in an outer frame")
+	}
+	regs, err := d.registers()
+	if err != nil {
+		return err
+	}
+	old, ok := regs.Dwarf()[n]
+	if !ok {
+		return fmt.Errorf("register %d not available", n)
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], old)
+	copy(buf[:], data)
+	v := binary.LittleEndian.Uint64(buf[:])
+	if !regs.SetDwarf(n, v) {
+		return fmt.Errorf("register %d cannot be modified", n)
+	}
+	if err := d.setRegisters(regs); err != nil {
+		return fmt.Errorf("set registers failed: %v", err)
+	}
+	if frame.regs != nil {
+		frame.regs[n] = v
+	}
+	return nil
+}
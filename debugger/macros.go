@@ -0,0 +1,245 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxMacroDepth 别名和宏互相展开的最大嵌套层数
+const maxMacroDepth = 16
+
+// macroArg 宏体中的位置参数：$1..$9 为第 N 个参数，$* 为全部参数，$# 为参数个数
+var macroArg = regexp.MustCompile(`\$([1-9]|\*|#)`)
+
+// cmdAlias alias 列出所有别名，alias name 显示一个别名，alias name [=] command... 定义别名
+func (r *REPL) cmdAlias(args []string) error {
+	if len(args) == 0 {
+		res := aliasesResult{Aliases: []aliasEntry{}}
+		for _, name := range sortedKeys(r.aliases) {
+			res.Aliases = append(res.Aliases, aliasEntry{Name: name, Command: r.aliases[name]})
+		}
+		r.output(res)
+		return nil
+	}
+
+	name := args[0]
+	expansion := args[1:]
+	if len(expansion) > 0 && expansion[0] == "=" {
+		expansion = expansion[1:]
+	}
+	if len(expansion) == 0 {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: alias <name> [=] <command> [args...]")
+		}
+		target, ok := r.aliases[name]
+		if !ok {
+			return fmt.Errorf("no alias named '%s'", name)
+		}
+		r.output(aliasesResult{Aliases: []aliasEntry{{Name: name, Command: target}}})
+		return nil
+	}
+
+	if err := r.checkUserCommandName(name); err != nil {
+		return err
+	}
+	delete(r.macros, name)
+	r.aliases[name] = strings.Join(expansion, " ")
+	return r.persistUserCommand(name)
+}
+
+// cmdDefine define name 读取到 end 为止的命令作为宏体
+func (r *REPL) cmdDefine(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: define <name>")
+	}
+	name := args[0]
+	if err := r.checkUserCommandName(name); err != nil {
+		return err
+	}
+	if r.input == nil {
+		return fmt.Errorf("define requires interactive or script input")
+	}
+
+	if r.interactive() {
+		fmt.Fprintf(r.out, "Type commands for definition of \"%s\".\nEnd with a line saying just \"end\".\n", name)
+	}
+	body, err := r.readBody()
+	if err != nil {
+		return err
+	}
+	delete(r.aliases, name)
+	r.macros[name] = body
+	return r.persistUserCommand(name)
+}
+
+// cmdUnalias 删除用户定义的别名或宏
+func (r *REPL) cmdUnalias(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: unalias <name>")
+	}
+	name := args[0]
+	_, isAlias := r.aliases[name]
+	_, isMacro := r.macros[name]
+	if !isAlias && !isMacro {
+		return fmt.Errorf("no alias or macro named '%s'", name)
+	}
+	delete(r.aliases, name)
+	delete(r.macros, name)
+	return r.persistUserCommand(name)
+}
+
+// checkUserCommandName 用户命令不能覆盖内置命令
+func (r *REPL) checkUserCommandName(name string) error {
+	if lookupCommand(name) != nil {
+		return fmt.Errorf("cannot redefine built-in command '%s'", name)
+	}
+	if strings.ContainsAny(name, "$#") {
+		return fmt.Errorf("invalid command name: %s", name)
+	}
+	return nil
+}
+
+// runAlias 把别名展开为目标命令，调用时的参数追加在后面
+func (r *REPL) runAlias(name, expansion string, args []string) error {
+	if r.macroDepth >= maxMacroDepth {
+		return fmt.Errorf("%s: aliases and macros nested too deeply", name)
+	}
+	r.macroDepth++
+	defer func() { r.macroDepth-- }()
+
+	fields := strings.Fields(expansion)
+	return r.executeCommand(fields[0], append(fields[1:], args...))
+}
+
+// runMacro 逐行执行宏体，遇到第一个错误即停止
+func (r *REPL) runMacro(name string, body []string, args []string) error {
+	if r.macroDepth >= maxMacroDepth {
+		return fmt.Errorf("%s: aliases and macros nested too deeply", name)
+	}
+	r.macroDepth++
+	defer func() { r.macroDepth-- }()
+
+	for _, line := range body {
+		line = expandMacroArgs(line, args)
+		if err := r.executeLine(line); err != nil {
+			return fmt.Errorf("%s: %s: %w", name, line, err)
+		}
+		if r.quit {
+			break
+		}
+	}
+	return nil
+}
+
+// expandMacroArgs 替换宏体中的位置参数，缺少的参数展开为空
+func expandMacroArgs(line string, args []string) string {
+	return macroArg.ReplaceAllStringFunc(line, func(m string) string {
+		switch m[1:] {
+		case "*":
+			return strings.Join(args, " ")
+		case "#":
+			return strconv.Itoa(len(args))
+		}
+		n, _ := strconv.Atoi(m[1:])
+		if n > len(args) {
+			return ""
+		}
+		return args[n-1]
+	})
+}
+
+// resolveCommand 沿别名找到最终的内置命令，宏或未知命令返回 nil
+func (r *REPL) resolveCommand(command string) *commandInfo {
+	for depth := 0; depth < maxMacroDepth; depth++ {
+		expansion, ok := r.aliases[command]
+		if !ok {
+			break
+		}
+		command = strings.Fields(expansion)[0]
+	}
+	return lookupCommand(command)
+}
+
+// repeatable 命令（或别名的目标命令）在空行时是否重复执行
+func (r *REPL) repeatable(command string) bool {
+	info := r.resolveCommand(command)
+	return info != nil && info.repeat
+}
+
+// userInitFile 用户定义的别名和宏保存在 $HOME/.tzdbinit 中
+func userInitFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".tzdbinit")
+}
+
+// userCommandLines 返回在初始化文件中定义 name 的命令行，name 已被删除时为空
+func (r *REPL) userCommandLines(name string) []string {
+	if expansion, ok := r.aliases[name]; ok {
+		return []string{fmt.Sprintf("alias %s = %s", name, expansion)}
+	}
+	if body, ok := r.macros[name]; ok {
+		lines := []string{"define " + name}
+		for _, line := range body {
+			lines = append(lines, "  "+line)
+		}
+		return append(lines, "end")
+	}
+	return nil
+}
+
+// persistUserCommand 在交互模式下把 name 的最新定义写回用户初始化文件，替换旧定义。
+// 执行脚本（包括初始化文件本身）时不写文件。
+func (r *REPL) persistUserCommand(name string) error {
+	if r.sourceDepth > 0 || r.inCommands || r.macroDepth > 0 {
+		return nil
+	}
+	path := userInitFile()
+	if path == "" {
+		return nil
+	}
+
+	var lines []string
+	if data, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		if len(lines) == 1 && lines[0] == "" {
+			lines = nil
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	var kept []string
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		if len(fields) >= 2 && fields[1] == name {
+			switch fields[0] {
+			case "alias":
+				continue
+			case "define":
+				// 跳过整个宏体
+				for i < len(lines) && strings.TrimSpace(lines[i]) != "end" {
+					i++
+				}
+				continue
+			}
+		}
+		kept = append(kept, lines[i])
+	}
+	kept = append(kept, r.userCommandLines(name)...)
+
+	content := strings.Join(kept, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to save %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,417 @@
+package debugger
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OutputFormat REPL 的输出格式
+type OutputFormat int
+
+const (
+	OutputText OutputFormat = iota // 面向人的文本
+	OutputJSON                     // 每条命令输出一行 JSON，便于脚本处理和 golden 测试
+)
+
+// ParseOutputFormat 解析 --output 参数
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch s {
+	case "", "text":
+		return OutputText, nil
+	case "json":
+		return OutputJSON, nil
+	}
+	return OutputText, fmt.Errorf("unknown output format: %s (want text or json)", s)
+}
+
+var (
+	errNoProgram      = errors.New("no program loaded")
+	errNotRunning     = errors.New("process is not running")
+	errUnknownCommand = errors.New("unknown command")
+)
+
+// Record JSON 模式下一条命令的输出：命令行、结果、执行期间产生的事件、
+// 其间嵌套执行的命令（宏、source、断点命令列表）以及错误
+type Record struct {
+	Command  string        `json:"command"`
+	OK       bool          `json:"ok"`
+	Result   commandResult `json:"result,omitempty"`
+	Events   []Event       `json:"events,omitempty"`
+	Commands []*Record     `json:"commands,omitempty"`
+	Error    *RecordError  `json:"error,omitempty"`
+}
+
+// RecordError 带错误码的错误，错误码见 errorCode
+type RecordError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorCode 把错误归类为稳定的错误码
+func errorCode(err error) string {
+	var unsupportedErr *ErrUnsupported
+	switch {
+	case errors.As(err, &unsupportedErr):
+		return "unsupported"
+	case errors.Is(err, errNoProgram):
+		return "no_program"
+	case errors.Is(err, errNotRunning):
+		return "not_running"
+	case errors.Is(err, errUnknownCommand):
+		return "unknown_command"
+	case strings.HasPrefix(err.Error(), "usage:"):
+		return "usage"
+	}
+	return "error"
+}
+
+// commandResult 命令的结构化结果，文本模式下由 printText 输出
+type commandResult interface {
+	printText(w io.Writer)
+}
+
+// output 输出命令结果：文本模式立即打印，JSON 模式记入当前命令的 Record
+func (r *REPL) output(res commandResult) {
+	if r.Format != OutputJSON {
+		r.outputMu.Lock()
+		defer r.outputMu.Unlock()
+		res.printText(r.out)
+		return
+	}
+	if len(r.records) == 0 {
+		r.writeRecord(&Record{OK: true, Result: res})
+		return
+	}
+	r.records[len(r.records)-1].Result = res
+}
+
+// onEvent 接收调试器事件，JSON 模式下记入当前命令的 Record
+func (r *REPL) onEvent(e Event) {
+	if r.Format != OutputJSON {
+		// 程序的输出在另一个 goroutine 中写入 out，停在行中间时（如输入提示）先换行
+		r.outputMu.Lock()
+		defer r.outputMu.Unlock()
+		if r.outputMidLine {
+			fmt.Fprintln(r.out)
+			r.outputMidLine = false
+		}
+		fmt.Fprintln(r.out, e.String())
+		return
+	}
+	if len(r.records) == 0 {
+		r.writeRecord(&Record{OK: true, Events: []Event{e}})
+		return
+	}
+	top := r.records[len(r.records)-1]
+	top.Events = append(top.Events, e)
+}
+
+// onOutput 在转发 goroutine 中接收程序的终端输出。文本模式下每行加上前缀，
+// JSON 模式下每段输出作为单独一行的 output 事件
+func (r *REPL) onOutput(prefix string, data []byte) {
+	if r.Format == OutputJSON {
+		r.writeRecord(&Record{OK: true, Events: []Event{{Kind: EventOutput, Output: string(data)}}})
+		return
+	}
+	r.outputMu.Lock()
+	defer r.outputMu.Unlock()
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		if !r.outputMidLine {
+			b.WriteString(prefix)
+		}
+		b.WriteString(line)
+		r.outputMidLine = !strings.HasSuffix(line, "\n")
+		if r.printOutput != nil {
+			r.printOutput(strings.TrimSuffix(b.String(), "\n"))
+			b.Reset()
+		}
+	}
+	io.WriteString(r.out, b.String())
+}
+
+// writeRecord 以一行 JSON 输出
+func (r *REPL) writeRecord(rec *Record) {
+	r.outputMu.Lock()
+	defer r.outputMu.Unlock()
+	enc := json.NewEncoder(r.out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(rec); err != nil {
+		enc.Encode(&Record{Command: rec.Command, Error: &RecordError{Code: "error", Message: err.Error()}})
+	}
+}
+
+// messageResult 只有一句提示的结果
+type messageResult struct {
+	Message string `json:"message"`
+}
+
+func (res messageResult) printText(w io.Writer) {
+	fmt.Fprintln(w, res.Message)
+}
+
+// stopResult continue/step 之后程序停下的位置和原因，文本模式下多数原因已经由事件描述
+type stopResult struct {
+	Running    bool           `json:"running"`
+	Reason     *StopReason    `json:"reason,omitempty"`
+	Breakpoint int            `json:"breakpoint,omitempty"`
+	Frame      *Frame         `json:"frame,omitempty"`
+	Displays   []displayValue `json:"displays,omitempty"`
+}
+
+// 断点、信号、退出等停止原因已经随事件打印，这里只输出 step、next 停下的位置和 display 的表达式
+func (res stopResult) printText(w io.Writer) {
+	if res.Reason != nil && res.Reason.Kind == StopStep {
+		fmt.Fprintln(w, res.Reason)
+	}
+	for _, v := range res.Displays {
+		v.printText(w)
+	}
+}
+
+type registersResult struct {
+	Registers []namedRegister `json:"registers"`
+}
+
+func (res registersResult) printText(w io.Writer) {
+	fmt.Fprintln(w, "Registers:")
+	for _, reg := range res.Registers {
+		fmt.Fprintf(w, "  %s: 0x%016x\n", reg.Name, reg.Value)
+	}
+}
+
+type memoryResult struct {
+	Address uint64 `json:"address"`
+	Hex     string `json:"hex"`
+	data    []byte
+}
+
+func newMemoryResult(address uint64, data []byte) memoryResult {
+	return memoryResult{Address: address, Hex: hex.EncodeToString(data), data: data}
+}
+
+func (res memoryResult) printText(w io.Writer) {
+	printMemoryDump(w, res.Address, res.data)
+}
+
+type stackResult struct {
+	Frames []Frame `json:"frames"`
+}
+
+func (res stackResult) printText(w io.Writer) {
+	fmt.Fprintln(w, "Stack trace:")
+	for i, frame := range res.Frames {
+		fmt.Fprintf(w, "  #%d: %s\n", i, frame)
+	}
+}
+
+// frameResult frame/up/down 选中的帧
+type frameResult struct {
+	Index int   `json:"index"`
+	Frame Frame `json:"frame"`
+}
+
+func (res frameResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "#%d: %s\n", res.Index, res.Frame)
+}
+
+// breakpointInfo 断点及其所在的源码位置
+type breakpointInfo struct {
+	ID        int      `json:"id"`
+	Address   uint64   `json:"address"`
+	Enabled   bool     `json:"enabled"`
+	HitCount  int      `json:"hit_count"`
+	Location  Frame    `json:"location"`
+	Spec      string   `json:"spec,omitempty"`
+	Condition string   `json:"condition,omitempty"`
+	Commands  []string `json:"commands,omitempty"`
+}
+
+type breakpointsResult struct {
+	Breakpoints []breakpointInfo `json:"breakpoints"`
+	Catchpoints []catchpointInfo `json:"catchpoints,omitempty"`
+}
+
+func (res breakpointsResult) printText(w io.Writer) {
+	if len(res.Breakpoints) == 0 {
+		fmt.Fprintln(w, "No breakpoints set")
+	} else {
+		fmt.Fprintln(w, "Breakpoints:")
+	}
+	for _, bp := range res.Breakpoints {
+		status := "enabled"
+		if !bp.Enabled {
+			status = "disabled"
+		}
+		fmt.Fprintf(w, "  %d: 0x%x (%s) %s, hit %d times\n", bp.ID, bp.Address, status, bp.Location.String(), bp.HitCount)
+		if bp.Condition != "" {
+			fmt.Fprintf(w, "        stop only if %s\n", bp.Condition)
+		}
+		for _, line := range bp.Commands {
+			fmt.Fprintf(w, "        %s\n", line)
+		}
+	}
+	if len(res.Catchpoints) > 0 {
+		fmt.Fprintln(w, "Catchpoints:")
+	}
+	for _, c := range res.Catchpoints {
+		status := "enabled"
+		if !c.Enabled {
+			status = "disabled"
+		}
+		fmt.Fprintf(w, "  %d: %s (%s), hit %d times\n", c.ID, c.What, status, c.HitCount)
+	}
+}
+
+// variableResult print 读到的变量内容
+// variableResult print 的输出：按类型格式化的值，指定了大小时是原始字节。
+// 寄存器中的变量地址为 0
+type variableResult struct {
+	Variable
+	Hex  string `json:"hex,omitempty"`
+	data []byte
+}
+
+func (res variableResult) printText(w io.Writer) {
+	if res.data == nil {
+		fmt.Fprintf(w, "%s = %s\n", res.Name, res.Value)
+		return
+	}
+	if res.Address != 0 {
+		fmt.Fprintf(w, "%s (0x%x): ", res.Name, res.Address)
+	} else {
+		fmt.Fprintf(w, "%s (register): ", res.Name)
+	}
+	for _, b := range res.data {
+		fmt.Fprintf(w, "%02x ", b)
+	}
+	fmt.Fprintln(w)
+}
+
+// assignResult set 写入的变量和写入后的值
+type assignResult struct {
+	Variable
+}
+
+func (res assignResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Set %s = %s\n", res.Name, res.Value)
+}
+
+// signalEntry 一个信号的处理策略
+type signalEntry struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+	SignalPolicy
+	Desc string `json:"desc"`
+}
+
+type signalsResult struct {
+	Signals []signalEntry `json:"signals"`
+	lines   []string
+}
+
+func newSignalsResult(t *SignalTable, sigs []int) signalsResult {
+	res := signalsResult{lines: t.Format(sigs)}
+	if len(sigs) == 0 {
+		for _, info := range knownSignals {
+			sigs = append(sigs, info.Number)
+		}
+	}
+	for _, sig := range sigs {
+		res.Signals = append(res.Signals, signalEntry{
+			Name: signalName(sig), Number: sig, SignalPolicy: t.Policy(sig), Desc: signalDesc(sig),
+		})
+	}
+	return res
+}
+
+func (res signalsResult) printText(w io.Writer) {
+	for _, line := range res.lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// aliasEntry 用户定义的别名
+type aliasEntry struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+type aliasesResult struct {
+	Aliases []aliasEntry `json:"aliases"`
+}
+
+func (res aliasesResult) printText(w io.Writer) {
+	if len(res.Aliases) == 0 {
+		fmt.Fprintln(w, "No aliases defined")
+	}
+	for _, alias := range res.Aliases {
+		fmt.Fprintf(w, "alias %s = %s\n", alias.Name, alias.Command)
+	}
+}
+
+// helpEntry 一条命令的帮助
+type helpEntry struct {
+	Names     []string `json:"names"`
+	Usage     string   `json:"usage,omitempty"`
+	Desc      string   `json:"desc"`
+	Supported bool     `json:"supported"`
+}
+
+// userCommandEntry 用户定义的别名或宏
+type userCommandEntry struct {
+	Name  string   `json:"name"`
+	Alias string   `json:"alias,omitempty"`
+	Macro []string `json:"macro,omitempty"`
+}
+
+type helpResult struct {
+	Commands []helpEntry        `json:"commands"`
+	User     []userCommandEntry `json:"user,omitempty"`
+}
+
+func (res helpResult) printText(w io.Writer) {
+	fmt.Fprintln(w, "Available commands:")
+	for _, info := range res.Commands {
+		usage := strings.Join(info.Names, ", ")
+		if info.Usage != "" {
+			usage += " " + info.Usage
+		}
+		desc := info.Desc
+		if !info.Supported {
+			desc += " (unsupported on this platform)"
+		}
+		fmt.Fprintf(w, "  %-26s - %s\n", usage, desc)
+	}
+
+	if len(res.User) > 0 {
+		fmt.Fprintln(w, "\nUser-defined commands:")
+		for _, cmd := range res.User {
+			if cmd.Macro != nil {
+				fmt.Fprintf(w, "  %-26s - macro (%d commands)\n", cmd.Name, len(cmd.Macro))
+			} else {
+				fmt.Fprintf(w, "  %-26s - alias for '%s'\n", cmd.Name, cmd.Alias)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, `
+Examples:
+  launch ./myprogram arg1 arg2
+  break main
+  break 0x401000
+  break main.go:12
+  memory 0x7fff12345678 32
+  x/4xg $rsp
+  x/5i main.main
+  handle SIGPIPE nostop noprint pass
+  alias fib = break main.fibonacci
+  `)
+}
@@ -0,0 +1,165 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"fmt"
+)
+
+// panicFuncs panic 捕获点在这些运行时函数的序言之后设置内部断点：
+// gopanic 在每次 panic（包括之后被 recover 的）时调用，
+// fatalpanic 和 fatalthrow 在未恢复的 panic 和运行时致命错误终止程序之前调用
+var panicFuncs = []string{"runtime.gopanic", "runtime.fatalpanic", "runtime.fatalthrow"}
+
+// defaultPanicCatchpoint 新建的调试器默认带有的 panic 捕获点，编号为 0，不占用断点编号
+func defaultPanicCatchpoint() *Catchpoint {
+	return &Catchpoint{Kind: CatchPanic, Enabled: true}
+}
+
+// CatchPanics 添加 panic 捕获点，已经存在时返回错误
+func (d *Debugger) CatchPanics() (*Catchpoint, error) {
+	for _, c := range d.Catchpoints {
+		if c.Kind == CatchPanic {
+			return nil, fmt.Errorf("panic catchpoint %d already set", c.ID)
+		}
+	}
+	c := d.addCatchpoint(CatchPanic, nil)
+	if d.IsRunning {
+		if err := d.installCatchpoint(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// installCatchpoints 程序启动或 exec 之后为 panic 捕获点设置内部断点
+func (d *Debugger) installCatchpoints() error {
+	for _, c := range d.Catchpoints {
+		if c.Kind != CatchPanic {
+			continue
+		}
+		if err := d.installCatchpoint(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Debugger) installCatchpoint(c *Catchpoint) error {
+	c.addrs = make(map[uint64]string)
+	for _, name := range panicFuncs {
+		addr, err := d.FindFunction(name)
+		if err != nil {
+			// 不是 Go 程序，或者运行时中没有这个函数
+			continue
+		}
+		if _, exists := d.Breakpoints[addr]; !exists {
+			orig, err := d.insertBreakpoint(addr)
+			if err != nil {
+				return err
+			}
+			d.Breakpoints[addr] = &Breakpoint{Address: addr, Original: orig, Enabled: true, internal: true}
+		}
+		c.addrs[addr] = name
+	}
+	return nil
+}
+
+// uninstallCatchpoint 移除 panic 捕获点的内部断点，同一地址上的用户断点保留
+func (d *Debugger) uninstallCatchpoint(c *Catchpoint) error {
+	for addr := range c.addrs {
+		bp, ok := d.Breakpoints[addr]
+		if !ok || !bp.internal {
+			continue
+		}
+		if d.IsRunning {
+			if err := d.clearBreakpoint(bp); err != nil {
+				return err
+			}
+		}
+		delete(d.Breakpoints, addr)
+	}
+	c.addrs = nil
+	return nil
+}
+
+// panicCatchpointAt 返回在 addr 处设置了断点的 panic 捕获点以及该处的运行时函数
+func (d *Debugger) panicCatchpointAt(addr uint64) (*Catchpoint, string) {
+	for _, c := range d.Catchpoints {
+		if name, ok := c.addrs[addr]; ok {
+			return c, name
+		}
+	}
+	return nil, ""
+}
+
+// panicEvent 停在 panic 捕获点时读取 panic 的值和当前 goroutine 的调用栈
+func (d *Debugger) panicEvent(c *Catchpoint, fn string, tid int) Event {
+	event := Event{Kind: EventPanic, Thread: tid, Catchpoint: c.ID}
+	frames, err := d.stacktrace(maxStackDepth)
+	if err != nil || len(frames) == 0 {
+		event.Frame = d.locationOf(tid)
+		frames = nil
+	}
+	event.Stack = frames
+	switch fn {
+	case "runtime.gopanic":
+		event.Message = "panic"
+		if frames != nil {
+			if v, err := d.ReadVariable("e", &frames[0]); err == nil {
+				event.Panic = v.Value
+			}
+		}
+	case "runtime.fatalpanic":
+		event.Message = "unrecovered panic"
+		if frames != nil {
+			event.Panic = d.panicArg(&frames[0])
+		}
+	case "runtime.fatalthrow":
+		event.Message = "fatal error"
+		// 错误信息是调用 fatalthrow 的 runtime.throw 或 runtime.fatal 的参数
+		for i := range frames {
+			if frames[i].Func != "runtime.throw" && frames[i].Func != "runtime.fatal" {
+				continue
+			}
+			if v, err := d.ReadVariable("s", &frames[i]); err == nil {
+				event.Panic = v.Value
+			}
+			break
+		}
+	}
+	return event
+}
+
+// panicArg 读取 fatalpanic(msgs *_panic) 的参数所指的 _panic 记录中的 arg
+func (d *Debugger) panicArg(frame *Frame) string {
+	entry, loc, err := d.findVariable("msgs", frame)
+	if err != nil {
+		return ""
+	}
+	typ, err := d.entryType(entry)
+	if err != nil {
+		return ""
+	}
+	ptr, ok := underlying(typ).(*dwarf.PtrType)
+	if !ok {
+		return ""
+	}
+	record, ok := underlying(ptr.Type).(*dwarf.StructType)
+	if !ok {
+		return ""
+	}
+	v, addr := valueReader{d: d, data: loc.data}, loc.addr
+	if loc.data != nil {
+		addr = registerValueBase
+	}
+	w, err := v.words(addr, 1)
+	if err != nil || w[0] == 0 {
+		return ""
+	}
+	for _, f := range record.Field {
+		if f.Name == "arg" {
+			return valueReader{d: d}.format(f.Type, w[0]+uint64(f.ByteOffset), 0)
+		}
+	}
+	return ""
+}
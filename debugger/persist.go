@@ -0,0 +1,272 @@
+package debugger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectBreakpointsFile 项目目录中自动保存断点的文件
+const projectBreakpointsFile = ".tz-gin/breakpoints.json"
+
+// savedBreakpoint 断点文件中的一项。保存位置描述而不是地址，加载时重新解析；
+// 命中次数属于一次调试会话，不保存
+type savedBreakpoint struct {
+	Location  string   `json:"location"`
+	Condition string   `json:"condition,omitempty"`
+	Commands  []string `json:"commands,omitempty"`
+}
+
+type breakpointFile struct {
+	Breakpoints []savedBreakpoint `json:"breakpoints"`
+}
+
+// sourceDir 返回 main 包源码所在的目录
+func (d *Debugger) sourceDir() (string, error) {
+	if d.index == nil {
+		return "", fmt.Errorf("no DWARF data loaded")
+	}
+	fn := d.index.lookupFunc("main.main")
+	if fn == nil {
+		return "", fmt.Errorf("main.main not found, cannot locate the source directory")
+	}
+	file, _, err := d.index.pcToLine(fn.LowPC)
+	if err != nil {
+		return "", fmt.Errorf("cannot locate the source directory: %v", err)
+	}
+	dir := filepath.Dir(file)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("source directory %s not found (was the program built with -trimpath?)", dir)
+	}
+	return dir, nil
+}
+
+// projectDir 返回包含 main 包的模块根目录（go.mod 所在目录），没有 go.mod 时为 main 包目录
+func (d *Debugger) projectDir() (string, error) {
+	dir, err := d.sourceDir()
+	if err != nil {
+		return "", err
+	}
+	for cur := dir; ; {
+		if _, err := os.Stat(filepath.Join(cur, "go.mod")); err == nil {
+			return cur, nil
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return dir, nil
+		}
+		cur = parent
+	}
+}
+
+// isAddressSpec 位置描述只是一个地址，重新编译后可能落在指令中间，不能跨会话恢复
+func isAddressSpec(spec string) bool {
+	return spec == "" || strings.HasPrefix(spec, "0x")
+}
+
+// marshalBreakpoints 把当前的断点编码为断点文件的内容，只有地址的断点不保存，
+// 以 0x… 的形式在 skipped 中返回
+func (r *REPL) marshalBreakpoints() (data []byte, skipped []string, err error) {
+	file := breakpointFile{Breakpoints: []savedBreakpoint{}}
+	for _, bp := range r.Debugger.SortedBreakpoints() {
+		if isAddressSpec(bp.Spec) {
+			skipped = append(skipped, fmt.Sprintf("0x%x", bp.Address))
+			continue
+		}
+		file.Breakpoints = append(file.Breakpoints, savedBreakpoint{
+			Location:  bp.Spec,
+			Condition: bp.Condition,
+			Commands:  bp.Commands,
+		})
+	}
+	// 条件中的 < > & 保持原样，方便手工编辑
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(file); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), skipped, nil
+}
+
+// saveBreakpoints 把断点写入 path，目录不存在时创建，返回保存的个数和未保存的地址断点
+func (r *REPL) saveBreakpoints(path string) (int, []string, error) {
+	data, skipped, err := r.marshalBreakpoints()
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, nil, err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return 0, nil, err
+	}
+	return len(r.Debugger.SortedBreakpoints()) - len(skipped), skipped, nil
+}
+
+// loadBreakpoints 读取断点文件并逐个设置，无法解析的位置记入返回的错误列表
+func (r *REPL) loadBreakpoints(path string) (int, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	var file breakpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, nil, fmt.Errorf("invalid breakpoint file %s: %v", path, err)
+	}
+	loaded := 0
+	var failed []string
+	for _, saved := range file.Breakpoints {
+		if isAddressSpec(saved.Location) {
+			failed = append(failed, fmt.Sprintf("%s: address breakpoints are not restored (the binary may have been rebuilt)", saved.Location))
+			continue
+		}
+		if _, err := r.setSavedBreakpoint(saved); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", saved.Location, err))
+			continue
+		}
+		loaded++
+	}
+	return loaded, failed, nil
+}
+
+// setSavedBreakpoint 按位置描述设置断点并恢复条件和命令列表，命中次数从 0 开始；
+// 该地址已有断点时只更新这些属性
+func (r *REPL) setSavedBreakpoint(saved savedBreakpoint) (*Breakpoint, error) {
+	address, err := r.Debugger.ResolveLocation(saved.Location)
+	if err != nil {
+		return nil, err
+	}
+	if saved.Condition != "" {
+		if _, err := parseCondition(saved.Condition); err != nil {
+			return nil, err
+		}
+	}
+	bp, exists := r.Debugger.Breakpoints[address]
+	if !exists || bp.internal {
+		if err := r.Debugger.SetBreakpoint(address); err != nil {
+			return nil, err
+		}
+		bp = r.Debugger.Breakpoints[address]
+	}
+	bp.Spec, bp.Condition, bp.HitCount, bp.Commands = saved.Location, saved.Condition, 0, saved.Commands
+	return bp, nil
+}
+
+// projectBreakpointsPath 返回当前程序的自动保存文件路径
+func (r *REPL) projectBreakpointsPath() (string, error) {
+	dir, err := r.Debugger.projectDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, projectBreakpointsFile), nil
+}
+
+// autoSaveBreakpoints 每条命令执行后，断点有变化时写入项目目录的 .tz-gin/breakpoints.json。
+// 从未设置过断点的项目不会创建该文件。新出现的地址断点不保存，提示一次。
+func (r *REPL) autoSaveBreakpoints() {
+	if r.Debugger == nil || r.Debugger.index == nil {
+		return
+	}
+	data, skipped, err := r.marshalBreakpoints()
+	if err != nil {
+		return
+	}
+	r.warnUnsaved(skipped)
+	if bytes.Equal(data, r.savedBreakpoints) {
+		return
+	}
+	path, err := r.projectBreakpointsPath()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) && len(r.Debugger.SortedBreakpoints()) == 0 {
+		return
+	}
+	if _, _, err := r.saveBreakpoints(path); err != nil {
+		r.printError(fmt.Errorf("failed to save breakpoints to %s: %v", path, err))
+	}
+	r.savedBreakpoints = data
+}
+
+// warnUnsaved 对还没提示过的地址断点说明它们不会保存到断点文件
+func (r *REPL) warnUnsaved(skipped []string) {
+	current := make(map[string]bool, len(skipped))
+	var added []string
+	for _, addr := range skipped {
+		current[addr] = true
+		if !r.unsavedBreakpoints[addr] {
+			added = append(added, addr)
+		}
+	}
+	r.unsavedBreakpoints = current
+	if len(added) > 0 {
+		r.output(messageResult{Message: fmt.Sprintf("Address breakpoints are not saved to %s: %s", projectBreakpointsFile, strings.Join(added, ", "))})
+	}
+}
+
+// autoLoadBreakpoints launch 后恢复项目目录中保存的断点
+func (r *REPL) autoLoadBreakpoints() {
+	path, err := r.projectBreakpointsPath()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	loaded, failed, err := r.loadBreakpoints(path)
+	if err != nil {
+		r.printError(err)
+		return
+	}
+	r.output(loadBreakpointsResult{File: path, Loaded: loaded, Failed: failed})
+	r.savedBreakpoints, _, _ = r.marshalBreakpoints()
+}
+
+type loadBreakpointsResult struct {
+	File   string   `json:"file"`
+	Loaded int      `json:"loaded"`
+	Failed []string `json:"failed,omitempty"`
+}
+
+func (res loadBreakpointsResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Loaded %d breakpoints from %s\n", res.Loaded, res.File)
+	for _, f := range res.Failed {
+		fmt.Fprintf(w, "  not set: %s\n", f)
+	}
+}
+
+// cmdSaveBreakpoints save-breakpoints <file> 把断点保存到文件
+func (r *REPL) cmdSaveBreakpoints(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: save-breakpoints <file>")
+	}
+	n, skipped, err := r.saveBreakpoints(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to save breakpoints: %v", err)
+	}
+	msg := fmt.Sprintf("Saved %d breakpoints to %s", n, args[0])
+	if len(skipped) > 0 {
+		msg += fmt.Sprintf(" (address breakpoints not saved: %s)", strings.Join(skipped, ", "))
+	}
+	r.output(messageResult{Message: msg})
+	return nil
+}
+
+// cmdLoadBreakpoints load-breakpoints <file> 从文件加载断点
+func (r *REPL) cmdLoadBreakpoints(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: load-breakpoints <file>")
+	}
+	loaded, failed, err := r.loadBreakpoints(args[0])
+	if err != nil {
+		return err
+	}
+	r.output(loadBreakpointsResult{File: args[0], Loaded: loaded, Failed: failed})
+	return nil
+}
@@ -0,0 +1,675 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package debugger
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// ptraceOExitKill 调试器退出时内核自动杀死被调试进程（syscall 包在部分架构上未定义）
+const ptraceOExitKill = 0x100000
+
+// launch 以 PTRACE_TRACEME 方式启动程序，并等待其在 execve 处暂停
+func (d *Debugger) launch(args []string) error {
+	cmd, files, err := d.command(args)
+	if err != nil {
+		return err
+	}
+	// 独立进程组，终端的 Ctrl-C 只发给调试器，由调试器决定如何中断程序
+	cmd.SysProcAttr = &syscall.SysProcAttr{Ptrace: true, Setpgid: true}
+	if err := d.startCommand(cmd, files); err != nil {
+		return err
+	}
+	d.Process = cmd.Process
+	d.IsRunning = true
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(d.Process.Pid, &ws, 0, nil); err != nil {
+		return fmt.Errorf("wait4 failed: %v", err)
+	}
+	// Go 程序是多线程的，需要跟踪所有 clone 出来的线程，以及 fork 和 exec
+	if err := syscall.PtraceSetOptions(d.Process.Pid, ptraceOptions); err != nil {
+		return fmt.Errorf("ptrace setoptions failed: %v", err)
+	}
+	d.tid = d.Process.Pid
+	d.threads = map[int]*thread{d.tid: {tid: d.tid, stopped: true, started: true}}
+	return nil
+}
+
+// cont 恢复所有线程，直到命中断点、收到需要暂停的信号或进程退出
+func (d *Debugger) cont() error {
+	if err := d.handlePendingForks(); err != nil {
+		return err
+	}
+	if err := d.vforkBlocked(); err != nil {
+		return err
+	}
+	if d.record != nil && !d.calling {
+		return d.recordCont()
+	}
+	if _, err := d.stepOverBreakpoint(); err != nil {
+		return err
+	}
+	if !d.IsRunning {
+		return nil
+	}
+	d.interrupted.Store(false)
+	if err := d.resumeAll(); err != nil {
+		return err
+	}
+	for {
+		var ws syscall.WaitStatus
+		wpid, err := syscall.Wait4(-1, &ws, syscall.WALL, nil)
+		if err != nil {
+			return fmt.Errorf("wait4 failed: %v", err)
+		}
+		if (ws.Exited() || ws.Signaled()) && d.returnToVforkParent(wpid) {
+			if err := d.resumeAll(); err != nil {
+				return err
+			}
+			continue
+		}
+		if ws.Exited() || ws.Signaled() {
+			if d.threadExited(wpid, ws) {
+				return nil
+			}
+			continue
+		}
+		if !ws.Stopped() || d.earlyForkStop(wpid) {
+			continue
+		}
+
+		th := d.threadStopped(wpid)
+		sig := ws.StopSignal()
+		switch {
+		case sig == syscallTrap:
+			call, exit, ret, err := d.trackSyscall(th)
+			if err != nil {
+				return err
+			}
+			var c *Catchpoint
+			if call != nil && !d.calling {
+				c = d.syscallCatchpoint(call.nr)
+			}
+			if c == nil {
+				if err := d.resume(th); err != nil {
+					return err
+				}
+				continue
+			}
+			d.tid = wpid
+			if err := d.stopAll(); err != nil {
+				return err
+			}
+			c.HitCount++
+			d.emitStop(d.syscallEvent(c, wpid, call, exit, ret))
+			return nil
+		case sig == syscall.SIGTRAP && ws.TrapCause() == syscall.PTRACE_EVENT_CLONE:
+			d.addClonedThread(wpid)
+			if err := d.resume(th); err != nil {
+				return err
+			}
+			continue
+		case sig == syscall.SIGTRAP && (ws.TrapCause() == syscall.PTRACE_EVENT_FORK || ws.TrapCause() == syscall.PTRACE_EVENT_VFORK):
+			switched, err := d.handleFork(wpid, ws.TrapCause() == syscall.PTRACE_EVENT_VFORK)
+			if err != nil {
+				return err
+			}
+			if d.vforkChild != 0 {
+				return nil
+			}
+			if switched {
+				err = d.resumeAll()
+			} else {
+				err = d.resume(th)
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		case sig == syscall.SIGTRAP && ws.TrapCause() == syscall.PTRACE_EVENT_VFORK_DONE:
+			if err := d.vforkDone(wpid); err != nil {
+				return err
+			}
+			if err := d.resume(th); err != nil {
+				return err
+			}
+			continue
+		case sig == syscall.SIGTRAP && ws.TrapCause() == syscall.PTRACE_EVENT_EXEC:
+			if err := d.handleExec(); err != nil {
+				return err
+			}
+			if err := d.resumeAll(); err != nil {
+				return err
+			}
+			continue
+		case sig == syscall.SIGSTOP && th.started && d.interrupted.Swap(false):
+			// 用户按下 Ctrl-C
+			th.expectStop = false
+			d.tid = wpid
+			if err := d.stopAll(); err != nil {
+				return err
+			}
+			d.emitStop(Event{Kind: EventInterrupted, Thread: wpid, Frame: d.locationOf(wpid)})
+			return nil
+		case sig == syscall.SIGSTOP:
+			// 新线程的初始暂停、stopAll 遗留或外部发送的 SIGSTOP，都不交给程序
+			th.started, th.expectStop = true, false
+			if err := d.resume(th); err != nil {
+				return err
+			}
+			continue
+		case sig == syscall.SIGTRAP:
+			d.tid = wpid
+			if err := d.stopAll(); err != nil {
+				return err
+			}
+			regs, err := d.arch.GetRegisters(wpid)
+			if err != nil {
+				return fmt.Errorf("get registers failed: %v", err)
+			}
+			bpAddr := regs.PC() - d.arch.BreakpointPCOffset()
+			bp, ok := d.Breakpoints[bpAddr]
+			if ok && bp.Enabled {
+				// PC 回退到断点地址，断点指令保留，下次继续时再单步越过
+				if bpAddr != regs.PC() {
+					regs.SetPC(bpAddr)
+					if err := d.arch.SetRegisters(wpid, regs); err != nil {
+						return fmt.Errorf("set pc failed: %v", err)
+					}
+				}
+				c, fn := d.panicCatchpointAt(bpAddr)
+				if c != nil && c.Enabled && !d.calling {
+					c.HitCount++
+					d.emitStop(d.panicEvent(c, fn, wpid))
+					return nil
+				}
+				if bp.internal && c == nil {
+					return nil
+				}
+				if bp.internal || !d.conditionHolds(bp) {
+					// 条件不成立，或者注入的调用中发生了 panic，越过断点继续运行
+					if _, err := d.stepOverBreakpoint(); err != nil {
+						return err
+					}
+					if !d.IsRunning {
+						return nil
+					}
+					if err := d.resumeAll(); err != nil {
+						return err
+					}
+					continue
+				}
+				bp.HitCount++
+				d.hit = bp
+				d.emitStop(Event{Kind: EventBreakpointHit, Thread: wpid, Breakpoint: bp.ID, Address: bpAddr})
+				return nil
+			}
+			if !d.calling {
+				d.emitStop(Event{Kind: EventStopped, Thread: wpid, Signal: signalName(int(sig)), Frame: d.locationOf(wpid)})
+			}
+			return nil
+		}
+
+		policy := d.Signals.Policy(int(sig))
+		if policy.Pass {
+			th.pendingSig = sig
+		}
+		if policy.Stop {
+			d.tid = wpid
+			if err := d.stopAll(); err != nil {
+				return err
+			}
+			d.emitStop(Event{Kind: EventSignal, Thread: wpid, Signal: signalName(int(sig)), Frame: d.locationOf(wpid)})
+			return nil
+		}
+		if policy.Print {
+			d.emit(Event{Kind: EventSignal, Thread: wpid, Signal: signalName(int(sig))})
+		}
+		if err := d.resume(th); err != nil {
+			return err
+		}
+	}
+}
+
+// interrupt 向主线程发送 SIGSTOP，等待循环收到后暂停所有线程。
+// 可以在其他 goroutine 中调用，不涉及 ptrace 请求。
+func (d *Debugger) interrupt() error {
+	d.interrupted.Store(true)
+	// 记录模式下只有单步的线程在运行，它可能阻塞在系统调用中
+	tid := d.Process.Pid
+	if t := d.stepping.Load(); t != 0 {
+		tid = int(t)
+	}
+	if err := syscall.Tgkill(d.Process.Pid, tid, syscall.SIGSTOP); err != nil {
+		return fmt.Errorf("tgkill failed: %v", err)
+	}
+	return nil
+}
+
+// resume 恢复单个线程，并注入挂起的信号。有系统调用捕获点时在系统调用的进入和返回处停止。
+func (d *Debugger) resume(th *thread) error {
+	sig := th.pendingSig
+	th.pendingSig = 0
+	cont := syscall.PtraceCont
+	if d.catchingSyscalls() {
+		cont = syscall.PtraceSyscall
+	}
+	if err := cont(th.tid, int(sig)); err != nil {
+		if err == syscall.ESRCH {
+			// 线程已经退出
+			delete(d.threads, th.tid)
+			return nil
+		}
+		return fmt.Errorf("ptrace cont %d failed: %v", th.tid, err)
+	}
+	th.stopped = false
+	return nil
+}
+
+// resumeAll 恢复所有已暂停的线程
+func (d *Debugger) resumeAll() error {
+	for _, th := range d.threads {
+		if !th.stopped {
+			continue
+		}
+		if err := d.resume(th); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopAll 向所有运行中的线程发送 SIGSTOP 并等待它们暂停
+func (d *Debugger) stopAll() error {
+	for _, th := range d.threads {
+		if th.stopped || th.expectStop || !th.started {
+			continue
+		}
+		if err := syscall.Tgkill(d.Process.Pid, th.tid, syscall.SIGSTOP); err != nil {
+			if err == syscall.ESRCH {
+				delete(d.threads, th.tid)
+				continue
+			}
+			return fmt.Errorf("tgkill %d failed: %v", th.tid, err)
+		}
+		th.expectStop = true
+	}
+
+	for !d.allStopped() {
+		var ws syscall.WaitStatus
+		wpid, err := syscall.Wait4(-1, &ws, syscall.WALL, nil)
+		if err != nil {
+			return fmt.Errorf("wait4 failed: %v", err)
+		}
+		if ws.Exited() || ws.Signaled() {
+			if d.threadExited(wpid, ws) {
+				return nil
+			}
+			continue
+		}
+		if !ws.Stopped() || d.earlyForkStop(wpid) {
+			continue
+		}
+
+		th := d.threadStopped(wpid)
+		sig := ws.StopSignal()
+		switch {
+		case sig == syscallTrap:
+			// 只记录线程进入的系统调用，返回时的停止仍会被报告
+			if _, _, _, err := d.trackSyscall(th); err != nil {
+				return err
+			}
+		case sig == syscall.SIGSTOP && !th.started:
+			th.started = true
+		case sig == syscall.SIGSTOP && th.expectStop:
+			th.expectStop = false
+		case sig == syscall.SIGTRAP && ws.TrapCause() == syscall.PTRACE_EVENT_CLONE:
+			d.addClonedThread(wpid)
+		case sig == syscall.SIGTRAP && (ws.TrapCause() == syscall.PTRACE_EVENT_FORK || ws.TrapCause() == syscall.PTRACE_EVENT_VFORK):
+			// 线程停在 fork 事件处，下次恢复运行前再处理
+			d.pendingForks = append(d.pendingForks, pendingFork{tid: wpid, vfork: ws.TrapCause() == syscall.PTRACE_EVENT_VFORK})
+		case sig == syscall.SIGTRAP && ws.TrapCause() == syscall.PTRACE_EVENT_VFORK_DONE:
+			if err := d.vforkDone(wpid); err != nil {
+				return err
+			}
+		case sig == syscall.SIGTRAP && ws.TrapCause() == syscall.PTRACE_EVENT_EXEC:
+			if err := d.handleExec(); err != nil {
+				return err
+			}
+		case sig == syscall.SIGTRAP:
+			// 其他线程同时命中断点：回退 PC，恢复后会再次命中
+			if err := d.rewindBreakpoint(wpid); err != nil {
+				return err
+			}
+		default:
+			if d.Signals.Policy(int(sig)).Pass {
+				th.pendingSig = sig
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Debugger) allStopped() bool {
+	for _, th := range d.threads {
+		if !th.stopped {
+			return false
+		}
+	}
+	return true
+}
+
+// threadStopped 记录线程已暂停，未知线程视为刚 clone 出的新线程
+func (d *Debugger) threadStopped(tid int) *thread {
+	th, ok := d.threads[tid]
+	if !ok {
+		th = &thread{tid: tid}
+		d.threads[tid] = th
+	}
+	th.stopped = true
+	return th
+}
+
+// addClonedThread 处理 PTRACE_EVENT_CLONE，新线程会以 SIGSTOP 开始运行
+func (d *Debugger) addClonedThread(parent int) {
+	msg, err := syscall.PtraceGetEventMsg(parent)
+	if err != nil {
+		return
+	}
+	tid := int(msg)
+	if _, ok := d.threads[tid]; !ok {
+		d.threads[tid] = &thread{tid: tid}
+	}
+}
+
+// threadExited 处理线程退出，返回整个进程是否已经结束
+func (d *Debugger) threadExited(tid int, ws syscall.WaitStatus) bool {
+	delete(d.threads, tid)
+	if tid != d.Process.Pid {
+		return false
+	}
+	d.IsRunning = false
+	d.threads = nil
+	if d.vforkParent != nil {
+		// 跟随的 vfork 子进程没有 exec 就退出了
+		d.releaseProcess(*d.vforkParent)
+		d.vforkParent = nil
+	}
+	d.flushOutput()
+	if ws.Signaled() {
+		d.emitStop(Event{Kind: EventKilled, PID: tid, Signal: signalName(int(ws.Signal()))})
+	} else {
+		code := ws.ExitStatus()
+		d.emitStop(Event{Kind: EventExited, PID: tid, ExitCode: &code})
+	}
+	return true
+}
+
+// waitProcessExit 等待正在结束的进程中剩余的线程退出
+func (d *Debugger) waitProcessExit() error {
+	for d.IsRunning {
+		var ws syscall.WaitStatus
+		wpid, err := syscall.Wait4(-1, &ws, syscall.WALL, nil)
+		if err != nil {
+			return fmt.Errorf("wait4 failed: %v", err)
+		}
+		if ws.Exited() || ws.Signaled() {
+			d.threadExited(wpid, ws)
+		}
+	}
+	return nil
+}
+
+// rewindBreakpoint 若线程停在断点之后，把 PC 回退到断点地址
+func (d *Debugger) rewindBreakpoint(tid int) error {
+	offset := d.arch.BreakpointPCOffset()
+	if offset == 0 {
+		return nil
+	}
+	regs, err := d.arch.GetRegisters(tid)
+	if err != nil {
+		return fmt.Errorf("get registers failed: %v", err)
+	}
+	bp, ok := d.Breakpoints[regs.PC()-offset]
+	if !ok || !bp.Enabled {
+		return nil
+	}
+	regs.SetPC(bp.Address)
+	return d.arch.SetRegisters(tid, regs)
+}
+
+// locationOf 返回线程当前位置的符号化信息，读取寄存器失败时返回 nil
+func (d *Debugger) locationOf(tid int) *Frame {
+	regs, err := d.arch.GetRegisters(tid)
+	if err != nil {
+		return nil
+	}
+	frame := d.frameAt(regs.PC())
+	return &frame
+}
+
+// singleStep 在当前线程上执行一条机器指令，其他线程保持暂停
+func (d *Debugger) singleStep() error {
+	if err := d.callPending(); err != nil {
+		return err
+	}
+	if err := d.handlePendingForks(); err != nil {
+		return err
+	}
+	if err := d.vforkBlocked(); err != nil {
+		return err
+	}
+	if d.record != nil && !d.calling {
+		return d.recordStep()
+	}
+	stepped, err := d.stepOverBreakpoint()
+	if err != nil || stepped {
+		return err
+	}
+	return d.stepThread()
+}
+
+// stepOverBreakpoint 若当前 PC 处有断点，临时恢复原指令单步执行后再重新写入断点
+func (d *Debugger) stepOverBreakpoint() (bool, error) {
+	regs, err := d.arch.GetRegisters(d.tid)
+	if err != nil {
+		return false, fmt.Errorf("get registers failed: %v", err)
+	}
+	bp, ok := d.Breakpoints[regs.PC()]
+	if !ok || !bp.Enabled {
+		return false, nil
+	}
+	if _, err := syscall.PtracePokeData(d.tid, uintptr(bp.Address), bp.Original); err != nil {
+		return false, fmt.Errorf("restore original instruction failed: %v", err)
+	}
+	if err := d.stepThread(); err != nil {
+		return false, err
+	}
+	if d.IsRunning {
+		if _, err := syscall.PtracePokeData(d.tid, uintptr(bp.Address), d.arch.BreakpointInstr()); err != nil {
+			return false, fmt.Errorf("reinsert breakpoint failed: %v", err)
+		}
+	}
+	return true, nil
+}
+
+// stepThread 单步当前线程并等待完成，期间收到的信号按策略挂起
+func (d *Debugger) stepThread() error {
+	for {
+		if err := syscall.PtraceSingleStep(d.tid); err != nil {
+			return fmt.Errorf("ptrace singlestep failed: %v", err)
+		}
+		var ws syscall.WaitStatus
+		if _, err := syscall.Wait4(d.tid, &ws, syscall.WALL, nil); err != nil {
+			return fmt.Errorf("wait4 failed: %v", err)
+		}
+		if ws.Exited() || ws.Signaled() {
+			if d.threadExited(d.tid, ws) {
+				return nil
+			}
+			if ws.Signaled() {
+				// 致命信号会结束整个进程，等待主线程报告进程的结束方式
+				return d.waitProcessExit()
+			}
+			return fmt.Errorf("thread %d exited", d.tid)
+		}
+		sig := ws.StopSignal()
+		if sig == syscall.SIGTRAP {
+			switch ws.TrapCause() {
+			case syscall.PTRACE_EVENT_CLONE:
+				d.addClonedThread(d.tid)
+				continue
+			case syscall.PTRACE_EVENT_FORK, syscall.PTRACE_EVENT_VFORK:
+				d.pendingForks = append(d.pendingForks, pendingFork{tid: d.tid, vfork: ws.TrapCause() == syscall.PTRACE_EVENT_VFORK})
+				continue
+			case syscall.PTRACE_EVENT_VFORK_DONE:
+				if err := d.vforkDone(d.tid); err != nil {
+					return err
+				}
+				continue
+			case syscall.PTRACE_EVENT_EXEC:
+				return d.handleExec()
+			}
+			return nil
+		}
+		th := d.threads[d.tid]
+		if sig == syscall.SIGSTOP && th.expectStop {
+			th.expectStop = false
+			continue
+		}
+		if sig == syscall.SIGSTOP && d.interrupted.Load() && d.stepping.CompareAndSwap(int32(d.tid), 0) {
+			// 记录模式下被中断，阻塞的系统调用被打断，恢复时重新执行
+			return nil
+		}
+		if d.Signals.Policy(int(sig)).Pass {
+			th.pendingSig = sig
+		}
+	}
+}
+
+// insertBreakpoint 写入断点指令，返回被覆盖的原始字节
+func (d *Debugger) insertBreakpoint(address uint64) ([]byte, error) {
+	instr := d.arch.BreakpointInstr()
+	orig := make([]byte, len(instr))
+	if _, err := syscall.PtracePeekData(d.tid, uintptr(address), orig); err != nil {
+		return nil, fmt.Errorf("peek data failed: %v", err)
+	}
+	if _, err := syscall.PtracePokeData(d.tid, uintptr(address), instr); err != nil {
+		return nil, fmt.Errorf("poke data failed: %v", err)
+	}
+	return orig, nil
+}
+
+// clearBreakpoint 恢复断点处的原始字节
+func (d *Debugger) clearBreakpoint(bp *Breakpoint) error {
+	if _, err := syscall.PtracePokeData(d.tid, uintptr(bp.Address), bp.Original); err != nil {
+		return fmt.Errorf("restore original instruction failed: %v", err)
+	}
+	return nil
+}
+
+// registers 读取当前线程的寄存器
+func (d *Debugger) registers() (registers, error) {
+	return d.arch.GetRegisters(d.tid)
+}
+
+// setRegisters 写回当前线程的寄存器
+func (d *Debugger) setRegisters(regs registers) error {
+	return d.arch.SetRegisters(d.tid, regs)
+}
+
+// getRegisters 以名字 -> 值的形式返回寄存器
+func (d *Debugger) getRegisters() (map[string]uint64, error) {
+	regs, err := d.registers()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]uint64)
+	for _, reg := range regs.Named() {
+		result[reg.Name] = reg.Value
+	}
+	return result, nil
+}
+
+// readMemory 读取被调试进程的内存，断点处返回原始字节
+func (d *Debugger) readMemory(address uint64, size int) ([]byte, error) {
+	data := make([]byte, size)
+	n, err := syscall.PtracePeekData(d.tid, uintptr(address), data)
+	if err != nil {
+		return nil, fmt.Errorf("read memory at 0x%x failed: %v", address, err)
+	}
+	data = data[:n]
+	for _, bp := range d.Breakpoints {
+		if !bp.Enabled {
+			continue
+		}
+		for i, b := range bp.Original {
+			if addr := bp.Address + uint64(i); addr >= address && addr < address+uint64(len(data)) {
+				data[addr-address] = b
+			}
+		}
+	}
+	return data, nil
+}
+
+// writeMemory 写入被调试进程的内存，覆盖到断点时更新断点保存的原始字节
+func (d *Debugger) writeMemory(address uint64, data []byte) error {
+	if _, err := syscall.PtracePokeData(d.tid, uintptr(address), data); err != nil {
+		return fmt.Errorf("write memory at 0x%x failed: %v", address, err)
+	}
+	for _, bp := range d.Breakpoints {
+		if !bp.Enabled || bp.Address+uint64(len(bp.Original)) <= address || bp.Address >= address+uint64(len(data)) {
+			continue
+		}
+		for i := range bp.Original {
+			if addr := bp.Address + uint64(i); addr >= address && addr < address+uint64(len(data)) {
+				bp.Original[i] = data[addr-address]
+			}
+		}
+		if _, err := syscall.PtracePokeData(d.tid, uintptr(bp.Address), d.arch.BreakpointInstr()); err != nil {
+			return fmt.Errorf("reinsert breakpoint failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// detach 移除所有断点后让进程继续独立运行
+func (d *Debugger) detach() error {
+	for _, bp := range d.Breakpoints {
+		if bp.Enabled {
+			if err := d.clearBreakpoint(bp); err != nil {
+				return err
+			}
+		}
+	}
+	for _, th := range d.threads {
+		if err := syscall.PtraceDetach(th.tid); err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("ptrace detach %d failed: %v", th.tid, err)
+		}
+	}
+	d.threads = nil
+	return nil
+}
+
+// Capabilities ptrace 后端支持全部操作
+func (d *Debugger) Capabilities() Capabilities {
+	return Capabilities{
+		Launch:      true,
+		Continue:    true,
+		Step:        true,
+		Breakpoints: true,
+		Registers:   true,
+		ReadMemory:  true,
+		WriteMemory: true,
+		StackTrace:  true,
+		Detach:      true,
+		Kill:        true,
+		Call:        callArgRegs > 0,
+		Checkpoint:  true,
+		Record:      recordSupported,
+		Catch:       true,
+	}
+}
@@ -0,0 +1,94 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package debugger
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func nativeArch() arch { return nil }
+
+// 没有 ptrace 后端时只能启动程序并等待其退出
+func (d *Debugger) launch(args []string) error {
+	cmd, files, err := d.command(args)
+	if err != nil {
+		return err
+	}
+	if err := d.startCommand(cmd, files); err != nil {
+		return err
+	}
+	d.Process = cmd.Process
+	d.IsRunning = true
+	return nil
+}
+
+func (d *Debugger) cont() error {
+	state, err := d.Process.Wait()
+	if err != nil {
+		return fmt.Errorf("process wait failed: %v", err)
+	}
+	d.IsRunning = false
+	d.flushOutput()
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		d.emitStop(Event{Kind: EventKilled, PID: d.Process.Pid, Signal: signalName(int(ws.Signal()))})
+		return nil
+	}
+	code := state.ExitCode()
+	d.emitStop(Event{Kind: EventExited, PID: d.Process.Pid, ExitCode: &code})
+	return nil
+}
+
+func (d *Debugger) interrupt() error {
+	return unsupported("interrupt")
+}
+
+func (d *Debugger) singleStep() error {
+	return unsupported("step")
+}
+
+func (d *Debugger) insertBreakpoint(address uint64) ([]byte, error) {
+	return nil, unsupported("breakpoint")
+}
+
+func (d *Debugger) clearBreakpoint(bp *Breakpoint) error {
+	return unsupported("breakpoint")
+}
+
+func (d *Debugger) registers() (registers, error) {
+	return nil, unsupported("registers")
+}
+
+func (d *Debugger) setRegisters(regs registers) error {
+	return unsupported("registers")
+}
+
+func (d *Debugger) getRegisters() (map[string]uint64, error) {
+	return nil, unsupported("registers")
+}
+
+func (d *Debugger) locationOf(tid int) *Frame {
+	return nil
+}
+
+func (d *Debugger) readMemory(address uint64, size int) ([]byte, error) {
+	return nil, unsupported("memory read")
+}
+
+func (d *Debugger) writeMemory(address uint64, data []byte) error {
+	return unsupported("memory write")
+}
+
+func (d *Debugger) detach() error {
+	return unsupported("detach")
+}
+
+// Capabilities 仅支持启动、等待退出和终止进程
+func (d *Debugger) Capabilities() Capabilities {
+	return Capabilities{
+		Launch:   true,
+		Continue: true,
+		Kill:     true,
+	}
+}
@@ -0,0 +1,54 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// openPty 分配一个伪终端，返回主设备和从设备。从设备关闭回显和输出时 \n 到 \r\n 的转换，
+// 程序的输出原样转发，input 写入的内容也不会被回显。
+func openPty() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			master.Close()
+		}
+	}()
+
+	var unlock int32
+	if err := ioctl(master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		return nil, nil, fmt.Errorf("unlock pty failed: %v", err)
+	}
+	var n uint32
+	if err := ioctl(master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		return nil, nil, fmt.Errorf("get pty number failed: %v", err)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var t syscall.Termios
+	if err := ioctl(slave.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&t))); err == nil {
+		t.Lflag &^= syscall.ECHO
+		t.Oflag &^= syscall.ONLCR
+		ioctl(slave.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&t)))
+	}
+	return master, slave, nil
+}
+
+func ioctl(fd, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package debugger
+
+import "os"
+
+func openPty() (*os.File, *os.File, error) {
+	return nil, nil, unsupported("pty")
+}
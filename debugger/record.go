@@ -0,0 +1,225 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// defaultRecordSize 记录模式默认保留的指令条数
+const defaultRecordSize = 100000
+
+var errNoHistory = errors.New("no more reverse-execution history")
+
+// recordEntry 一条指令执行前的状态：被修改的寄存器和内存的旧值
+type recordEntry struct {
+	pc   uint64
+	regs []regChange
+	mem  []memChange
+}
+
+// regChange 寄存器在原始寄存器结构中的下标（按 8 字节计）和旧值
+type regChange struct {
+	index int
+	old   uint64
+}
+
+type memChange struct {
+	addr uint64
+	old  []byte
+}
+
+// memRange 一条指令将要写入的内存范围
+type memRange struct {
+	addr uint64
+	size int
+}
+
+// recordLog 环形缓冲区，满了以后覆盖最早的记录
+type recordLog struct {
+	entries []recordEntry
+	start   int
+	n       int
+	total   uint64 // 开始记录以来执行的指令数
+}
+
+func newRecordLog(size int) *recordLog {
+	return &recordLog{entries: make([]recordEntry, size)}
+}
+
+func (l *recordLog) push(e recordEntry) {
+	if l.n < len(l.entries) {
+		l.entries[(l.start+l.n)%len(l.entries)] = e
+		l.n++
+	} else {
+		l.entries[l.start] = e
+		l.start = (l.start + 1) % len(l.entries)
+	}
+	l.total++
+}
+
+// pop 取出最近的一条记录
+func (l *recordLog) pop() (recordEntry, bool) {
+	if l.n == 0 {
+		return recordEntry{}, false
+	}
+	l.n--
+	i := (l.start + l.n) % len(l.entries)
+	e := l.entries[i]
+	l.entries[i] = recordEntry{}
+	return e, true
+}
+
+// RecordInfo 记录模式的状态
+type RecordInfo struct {
+	Recording    bool   `json:"recording"`
+	Size         int    `json:"size,omitempty"`
+	Entries      int    `json:"entries"`
+	Instructions uint64 `json:"instructions"`
+}
+
+// StartRecord 开启记录模式：之后的 continue、step 改为逐条指令单步执行（只运行当前线程），
+// 每条指令执行前保存寄存器和将被写入的内存，最多保留 size 条
+func (d *Debugger) StartRecord(size int) error {
+	if !d.IsRunning {
+		return errNotRunning
+	}
+	if !recordSupported {
+		return unsupported("record")
+	}
+	if size <= 0 {
+		size = defaultRecordSize
+	}
+	d.record = newRecordLog(size)
+	return nil
+}
+
+// StopRecord 关闭记录模式并丢弃已记录的历史
+func (d *Debugger) StopRecord() {
+	d.record = nil
+}
+
+// Record 返回记录模式的状态
+func (d *Debugger) Record() RecordInfo {
+	if d.record == nil {
+		return RecordInfo{}
+	}
+	return RecordInfo{Recording: true, Size: len(d.record.entries), Entries: d.record.n, Instructions: d.record.total}
+}
+
+// ReverseStep 撤销最近执行的一条指令，返回停下的原因
+func (d *Debugger) ReverseStep() (StopReason, error) {
+	if err := d.checkReverse(); err != nil {
+		return StopReason{}, err
+	}
+	return d.run(d.reverseStep)
+}
+
+func (d *Debugger) reverseStep() error {
+	entry, ok := d.record.pop()
+	if !ok {
+		return errNoHistory
+	}
+	return d.undo(entry)
+}
+
+// ReverseContinue 反向执行，直到回到某个断点，或者记录的历史用完，返回停下的原因
+func (d *Debugger) ReverseContinue() (StopReason, error) {
+	if err := d.checkReverse(); err != nil {
+		return StopReason{}, err
+	}
+	return d.run(d.reverseContinue)
+}
+
+func (d *Debugger) reverseContinue() error {
+	for {
+		entry, ok := d.record.pop()
+		if !ok {
+			d.emitStop(Event{Kind: EventHistoryStart, Frame: d.locationOf(d.tid)})
+			return nil
+		}
+		if err := d.undo(entry); err != nil {
+			return err
+		}
+		if bp, ok := d.Breakpoints[entry.pc]; ok && bp.Enabled && !bp.internal && d.conditionHolds(bp) {
+			bp.HitCount++
+			d.hit = bp
+			d.emitStop(Event{Kind: EventBreakpointHit, Thread: d.tid, Breakpoint: bp.ID, Address: bp.Address})
+			return nil
+		}
+	}
+}
+
+func (d *Debugger) checkReverse() error {
+	if !d.IsRunning {
+		return errNotRunning
+	}
+	if d.record == nil {
+		return fmt.Errorf("reverse execution requires record mode (use 'record' first)")
+	}
+	return nil
+}
+
+// undo 恢复一条指令执行前的内存和寄存器
+func (d *Debugger) undo(entry recordEntry) error {
+	for i := len(entry.mem) - 1; i >= 0; i-- {
+		if err := d.writeMemory(entry.mem[i].addr, entry.mem[i].old); err != nil {
+			return err
+		}
+	}
+	return d.restoreRegisters(entry.regs)
+}
+
+type recordResult struct {
+	RecordInfo
+}
+
+func (res recordResult) printText(w io.Writer) {
+	if !res.Recording {
+		fmt.Fprintln(w, "Process record is off.")
+		return
+	}
+	fmt.Fprintf(w, "Recording: %d of %d instructions in the log (%d executed since record started).\n",
+		res.Entries, res.Size, res.Instructions)
+}
+
+// cmdRecord record [size|stop|info] 开启、关闭或查看记录模式
+func (r *REPL) cmdRecord(args []string) error {
+	d := r.Debugger
+	if len(args) > 0 {
+		switch args[0] {
+		case "stop":
+			if d.record == nil {
+				return fmt.Errorf("process record is not started")
+			}
+			d.StopRecord()
+			r.output(messageResult{Message: "Process record is stopped and all execution logs are deleted."})
+			return nil
+		case "info":
+			r.output(recordResult{d.Record()})
+			return nil
+		}
+	}
+	size := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("usage: record [size|stop|info]")
+		}
+		size = n
+	}
+	if err := d.StartRecord(size); err != nil {
+		return err
+	}
+	r.output(recordResult{d.Record()})
+	return nil
+}
+
+func (r *REPL) cmdReverseStep(args []string) error {
+	return r.resume(r.Debugger.ReverseStep)
+}
+
+func (r *REPL) cmdReverseContinue(args []string) error {
+	return r.resume(r.Debugger.ReverseContinue)
+}
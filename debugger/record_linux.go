@@ -0,0 +1,130 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package debugger
+
+import "fmt"
+
+// maxInstrLen 读取指令时的最大长度（x86 指令最长 15 字节）
+const maxInstrLen = 16
+
+// recordStep 单步当前线程并把执行前的状态追加到记录中
+func (d *Debugger) recordStep() error {
+	before, err := d.arch.GetRegisters(d.tid)
+	if err != nil {
+		return fmt.Errorf("get registers failed: %v", err)
+	}
+	pc := before.PC()
+	code, err := d.readMemory(pc, maxInstrLen)
+	if err != nil {
+		return err
+	}
+	if name, ok := d.blockingSyscall(code, before); ok {
+		// 其他线程保持暂停，唤醒它的线程无法运行
+		return fmt.Errorf("thread %d is about to block in %s waiting for other threads; use 'record stop' to continue without recording", d.tid, name)
+	}
+	entry := recordEntry{pc: pc}
+	ranges, err := memoryWrites(code, before)
+	if err != nil {
+		return fmt.Errorf("cannot record instruction at 0x%x: %v", pc, err)
+	}
+	for _, m := range ranges {
+		old, err := d.readMemory(m.addr, m.size)
+		if err != nil {
+			// 写入不可访问的地址时指令会触发 SIGSEGV，不需要恢复
+			continue
+		}
+		entry.mem = append(entry.mem, memChange{addr: m.addr, old: old})
+	}
+
+	stepped, err := d.stepOverBreakpoint()
+	if err != nil {
+		return err
+	}
+	if !stepped {
+		if err := d.stepThread(); err != nil {
+			return err
+		}
+	}
+	if !d.IsRunning {
+		return nil
+	}
+
+	after, err := d.arch.GetRegisters(d.tid)
+	if err != nil {
+		return fmt.Errorf("get registers failed: %v", err)
+	}
+	old, cur := rawRegs(before), rawRegs(after)
+	for i := range old {
+		if old[i] != cur[i] {
+			entry.regs = append(entry.regs, regChange{index: i, old: old[i]})
+		}
+	}
+	d.record.push(entry)
+	return nil
+}
+
+// recordCont 记录模式下的 continue：逐条指令单步执行，直到到达断点、被中断或进程退出
+func (d *Debugger) recordCont() error {
+	d.interrupted.Store(false)
+	d.stepping.Store(int32(d.tid))
+	defer d.stepping.Store(0)
+	for {
+		if err := d.recordStep(); err != nil {
+			return err
+		}
+		if !d.IsRunning {
+			return nil
+		}
+		if d.interrupted.Swap(false) {
+			// stepThread 没有收到 interrupt 发给单步线程的 SIGSTOP 时，它在之后的等待中被忽略
+			if t := d.stepping.Swap(0); t != 0 {
+				if th := d.threads[int(t)]; th != nil {
+					th.expectStop = true
+				}
+			}
+			d.emitStop(Event{Kind: EventInterrupted, Thread: d.tid, Frame: d.locationOf(d.tid)})
+			return nil
+		}
+		regs, err := d.arch.GetRegisters(d.tid)
+		if err != nil {
+			return fmt.Errorf("get registers failed: %v", err)
+		}
+		bp, ok := d.Breakpoints[regs.PC()]
+		if !ok || !bp.Enabled {
+			continue
+		}
+		if c, fn := d.panicCatchpointAt(bp.Address); c != nil && c.Enabled {
+			c.HitCount++
+			d.emitStop(d.panicEvent(c, fn, d.tid))
+			return nil
+		}
+		if bp.internal {
+			return nil
+		}
+		if !d.conditionHolds(bp) {
+			continue
+		}
+		bp.HitCount++
+		d.hit = bp
+		d.emitStop(Event{Kind: EventBreakpointHit, Thread: d.tid, Breakpoint: bp.ID, Address: bp.Address})
+		return nil
+	}
+}
+
+// restoreRegisters 把记录中的寄存器旧值写回当前线程
+func (d *Debugger) restoreRegisters(changes []regChange) error {
+	regs, err := d.arch.GetRegisters(d.tid)
+	if err != nil {
+		return fmt.Errorf("get registers failed: %v", err)
+	}
+	raw := rawRegs(regs)
+	for _, c := range changes {
+		raw[c.index] = c.old
+	}
+	if err := d.arch.SetRegisters(d.tid, regs); err != nil {
+		return fmt.Errorf("set registers failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package debugger
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+const recordSupported = true
+
+// rawRegs 把 PtraceRegs 视为 8 字节字的数组，用于比较和恢复寄存器
+func rawRegs(regs registers) []uint64 {
+	raw := &regs.(*amd64Regs).raw
+	return unsafe.Slice((*uint64)(unsafe.Pointer(raw)), unsafe.Sizeof(*raw)/8)
+}
+
+// readOnlyMemOps 第一个操作数是内存但只读不写的指令
+var readOnlyMemOps = map[x86asm.Op]bool{
+	x86asm.CMP: true, x86asm.TEST: true, x86asm.BT: true,
+	x86asm.PUSH: true, x86asm.CALL: true, x86asm.JMP: true,
+	x86asm.UCOMISS: true, x86asm.UCOMISD: true, x86asm.COMISS: true, x86asm.COMISD: true,
+	x86asm.PREFETCHNTA: true, x86asm.PREFETCHT0: true, x86asm.PREFETCHT1: true,
+	x86asm.PREFETCHT2: true, x86asm.PREFETCHW: true, x86asm.CLFLUSH: true, x86asm.NOP: true,
+}
+
+// memoryWrites 解码 code 开头的指令，返回它将写入的内存范围（显式的目的操作数，
+// 以及 PUSH、CALL、STOS、MOVS 隐式写入的地址）。系统调用对内存的修改无法得知。
+func memoryWrites(code []byte, regs registers) ([]memRange, error) {
+	inst, err := x86asm.Decode(code, 64)
+	if err != nil {
+		return nil, err
+	}
+	r := &regs.(*amd64Regs).raw
+	next := r.Rip + uint64(inst.Len)
+
+	var ranges []memRange
+	switch inst.Op {
+	case x86asm.PUSH, x86asm.CALL, x86asm.PUSHFQ:
+		ranges = append(ranges, memRange{addr: r.Rsp - 8, size: 8})
+	case x86asm.STOSB, x86asm.MOVSB:
+		ranges = append(ranges, memRange{addr: r.Rdi, size: 1})
+	case x86asm.STOSW, x86asm.MOVSW:
+		ranges = append(ranges, memRange{addr: r.Rdi, size: 2})
+	case x86asm.STOSD, x86asm.MOVSD:
+		ranges = append(ranges, memRange{addr: r.Rdi, size: 4})
+	case x86asm.STOSQ, x86asm.MOVSQ:
+		ranges = append(ranges, memRange{addr: r.Rdi, size: 8})
+	}
+	if len(ranges) > 0 {
+		return ranges, nil
+	}
+
+	size := inst.MemBytes
+	if size == 0 {
+		size = 8
+	}
+	for i, arg := range inst.Args {
+		mem, ok := arg.(x86asm.Mem)
+		if !ok {
+			continue
+		}
+		// 目的操作数在第一个位置，XCHG 的两个操作数都会被写入
+		if i == 0 && !readOnlyMemOps[inst.Op] || i == 1 && inst.Op == x86asm.XCHG {
+			ranges = append(ranges, memRange{addr: effectiveAddr(mem, r, next), size: size})
+		}
+	}
+	return ranges, nil
+}
+
+// effectiveAddr 计算内存操作数的地址，RIP 相对寻址以下一条指令为基准
+func effectiveAddr(m x86asm.Mem, r *syscall.PtraceRegs, next uint64) uint64 {
+	addr := uint64(m.Disp)
+	if m.Base != 0 {
+		addr += gprValue(r, m.Base, next)
+	}
+	if m.Index != 0 {
+		addr += gprValue(r, m.Index, next) * uint64(m.Scale)
+	}
+	switch m.Segment {
+	case x86asm.FS:
+		addr += r.Fs_base
+	case x86asm.GS:
+		addr += r.Gs_base
+	}
+	return addr
+}
+
+// gprValue 通用寄存器的值，32 位寄存器取低 32 位
+func gprValue(r *syscall.PtraceRegs, reg x86asm.Reg, next uint64) uint64 {
+	gprs := [...]uint64{r.Rax, r.Rcx, r.Rdx, r.Rbx, r.Rsp, r.Rbp, r.Rsi, r.Rdi,
+		r.R8, r.R9, r.R10, r.R11, r.R12, r.R13, r.R14, r.R15}
+	switch {
+	case reg >= x86asm.RAX && reg <= x86asm.R15:
+		return gprs[reg-x86asm.RAX]
+	case reg >= x86asm.EAX && reg <= x86asm.R15L:
+		return gprs[reg-x86asm.EAX] & 0xffffffff
+	case reg == x86asm.RIP:
+		return next
+	case reg == x86asm.EIP:
+		return next & 0xffffffff
+	}
+	return 0
+}
+
+// futex 的操作（linux/futex.h）
+const (
+	futexWait          = 0
+	futexLockPI        = 6
+	futexWaitBitset    = 9
+	futexWaitRequeuePI = 11
+	futexLockPI2       = 13
+	futexCmdMask       = 0x7f
+)
+
+// blockingSyscall 判断 code 开头是否为会一直阻塞到其他线程唤醒它的系统调用：没有超时的 futex 等待，
+// 以及没有超时的 epoll、poll、select。有超时或不会阻塞的调用不算
+func (d *Debugger) blockingSyscall(code []byte, regs registers) (string, bool) {
+	if len(code) < 2 || code[0] != 0x0f || code[1] != 0x05 {
+		return "", false
+	}
+	r := &regs.(*amd64Regs).raw
+	nr, args := int(r.Rax), [6]uint64{r.Rdi, r.Rsi, r.Rdx, r.R10, r.R8, r.R9}
+	var blocks bool
+	switch nr {
+	case syscall.SYS_FUTEX:
+		switch args[1] & futexCmdMask {
+		case futexWait, futexWaitBitset:
+			// *uaddr 与期望值不同时立即返回 EAGAIN
+			if args[3] == 0 {
+				val, err := d.readMemory(args[0], 4)
+				blocks = err == nil && littleEndian(val) == args[2]&0xffffffff
+			}
+		case futexLockPI, futexWaitRequeuePI, futexLockPI2:
+			blocks = args[3] == 0
+		}
+	case syscall.SYS_EPOLL_WAIT, syscall.SYS_EPOLL_PWAIT:
+		blocks = int32(args[3]) < 0
+	case syscall.SYS_POLL:
+		blocks = int32(args[2]) < 0
+	case syscall.SYS_PPOLL:
+		blocks = args[2] == 0
+	case syscall.SYS_SELECT, syscall.SYS_PSELECT6:
+		blocks = args[4] == 0
+	case sysEpollPwait2:
+		blocks = args[3] == 0
+	}
+	return syscallName(nr), blocks
+}
+
+// sysEpollPwait2 syscall 包中没有定义
+const sysEpollPwait2 = 441
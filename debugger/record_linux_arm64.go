@@ -0,0 +1,22 @@
+//go:build linux && arm64
+// +build linux,arm64
+
+package debugger
+
+import "unsafe"
+
+// arm64 还没有实现从指令中解码内存写入
+const recordSupported = false
+
+func rawRegs(regs registers) []uint64 {
+	raw := &regs.(*arm64Regs).raw
+	return unsafe.Slice((*uint64)(unsafe.Pointer(raw)), unsafe.Sizeof(*raw)/8)
+}
+
+func memoryWrites(code []byte, regs registers) ([]memRange, error) {
+	return nil, unsupported("record")
+}
+
+func (d *Debugger) blockingSyscall(code []byte, regs registers) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,10 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package debugger
+
+const recordSupported = false
+
+func (d *Debugger) restoreRegisters(changes []regChange) error {
+	return unsupported("record")
+}
@@ -2,437 +2,528 @@ package debugger
 
 import (
 	"bufio"
-	"debug/dwarf"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
-	"syscall"
+	"sync"
+	"sync/atomic"
+
+	"github.com/peterh/liner"
 )
 
 // REPL 交互式调试器界面
 type REPL struct {
 	Debugger *Debugger
-	Scanner  *bufio.Scanner
-	// 当前帧信息
-	lastFunc string
-	lastRbp  uint64
-	lastRip  uint64
+	// Format 为 OutputJSON 时每条命令输出一行 JSON
+	Format OutputFormat
+	// 终端下提供行编辑、历史和补全，输入被重定向时退化为逐行读取
+	line     *liner.State
+	fallback *bufio.Scanner
+	// 信号处理策略和 fork 的处理方式在重新 launch 后保留
+	signals *SignalTable
+	fork    *ForkSettings
+	// 程序停下时的调用栈，以及 up/down/frame 选中的帧，变量在选中的帧中解析
+	frames   []Frame
+	frameIdx int
+	// 上一条命令，空行时若可重复则再次执行
+	lastLine string
+	quit     bool
+	// source 嵌套层数
+	sourceDepth int
+	// input 读取下一行输入，交互时来自终端，source 时来自脚本
+	input func(prompt string) (string, error)
+	// 正在执行断点命令列表
+	inCommands bool
+	// Continue 期间正在运行的调试器，供 Ctrl-C 中断
+	running atomic.Pointer[Debugger]
+	// 用户定义的别名（名字 -> 命令行）和宏（名字 -> 命令列表）
+	aliases    map[string]string
+	macros     map[string][]string
+	macroDepth int
+	// display 添加的表达式，每次程序停下时自动显示
+	displays      []display
+	nextDisplayID int
+	// 最近一次写入 .tz-gin/breakpoints.json 的内容，断点有变化时才重新写入
+	savedBreakpoints []byte
+	// 已经提示过不会保存的地址断点
+	unsavedBreakpoints map[string]bool
+	// JSON 模式下正在执行的命令，嵌套执行（宏、source、断点命令）时逐层压栈
+	records []*Record
+	// 程序的终端输出在另一个 goroutine 中到达，outputMidLine 表示上一段输出没有以换行结束
+	outputMu      sync.Mutex
+	outputMidLine bool
+	// out 命令结果和事件的输出，默认为标准输出，全屏界面中为底部的输出区域
+	out io.Writer
+	// printOutput 不为 nil 时（全屏界面）程序的输出按行交给它，而不是写到 out
+	printOutput func(line string)
 }
 
 func NewREPL(debugger *Debugger) *REPL {
-	return &REPL{
+	signals, fork := NewSignalTable(), NewForkSettings()
+	if debugger != nil {
+		signals, fork = debugger.Signals, debugger.Fork
+	}
+	r := &REPL{
 		Debugger: debugger,
-		Scanner:  bufio.NewScanner(os.Stdin),
+		signals:  signals,
+		fork:     fork,
+		aliases:  make(map[string]string),
+		macros:   make(map[string][]string),
+		out:      os.Stdout,
+	}
+	if debugger != nil {
+		debugger.OnEvent = r.onEvent
 	}
+	return r
 }
+
 func (r *REPL) Start() {
-	fmt.Println("TZGin2 Debugger v1.0")
-	fmt.Println("Type 'help' for available commands")
+	defer r.attach()()
+
+	r.input = r.readLine
+	if r.Format == OutputJSON {
+		// JSON 模式不做行编辑，也不输出提示符和欢迎信息
+		r.fallback = bufio.NewScanner(os.Stdin)
+	} else {
+		r.line = liner.NewLiner()
+		defer r.line.Close()
+		r.line.SetCtrlCAborts(true)
+		r.line.SetWordCompleter(r.complete)
+		r.loadHistory()
+		defer r.saveHistory()
+
+		fmt.Fprintln(r.out, "TZGin2 Debugger v1.0")
+		fmt.Fprintln(r.out, "Type 'help' for available commands")
+	}
 
-	for {
-		fmt.Print("(tzdb) ")
+	r.sourceInitFiles()
 
-		if !r.Scanner.Scan() {
+	for !r.quit {
+		input, err := r.readLine("(tzdb) ")
+		if err == liner.ErrPromptAborted {
+			continue
+		}
+		if err != nil {
 			break
 		}
 
-		line := strings.TrimSpace(r.Scanner.Text())
+		line := strings.TrimSpace(input)
 		if line == "" {
-			continue
-		}
-
-		parts := strings.Fields(line)
-		if len(parts) == 0 {
-			continue
+			// 空行重复上一条可重复的命令，如 step、continue
+			if r.lastLine == "" {
+				continue
+			}
+			line = r.lastLine
+		} else if r.line != nil {
+			r.line.AppendHistory(line)
 		}
 
-		command := parts[0]
-		args := parts[1:]
-
-		if err := r.executeCommand(command, args); err != nil {
-			fmt.Printf("Error: %v\n", err)
+		if err := r.executeLine(line); err != nil {
+			r.printError(err)
 		}
 	}
 }
 
-func (r *REPL) executeCommand(command string, args []string) error {
+// RunScript 批处理模式：执行初始化文件和脚本后退出，任何命令出错都返回错误
+func (r *REPL) RunScript(path string) error {
+	defer r.attach()()
 
-	switch command {
-	case "help", "h":
-		r.printHelp()
-
-	case "launch", "l":
-		if len(args) == 0 {
-			return fmt.Errorf("usage: launch <program> [args...]")
-		}
-		executable := args[0]
-		programArgs := args[1:]
-
-		debugger, err := NewDebugger(executable)
-		if err != nil {
-			return err
-		}
+	r.sourceInitFiles()
+	err := r.Source(path)
+	if r.Debugger != nil && r.Debugger.IsRunning {
+		// 脚本结束后的清理不是用户的命令，不输出事件
+		r.Debugger.OnEvent = func(Event) {}
+		r.Debugger.Kill()
+	}
+	return err
+}
 
-		r.Debugger = debugger
-		return r.Debugger.Launch(programArgs)
+// attach 准备在当前 goroutine 中驱动调试器，返回清理函数
+func (r *REPL) attach() func() {
+	// ptrace 请求必须来自启动被调试进程的线程
+	runtime.LockOSThread()
 
-	case "continue", "c":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
-		err := r.Debugger.Continue()
-		// 命中断点时，尝试获取 rip/rbp 并推断当前函数名
-		if err == nil && r.Debugger.IsRunning {
-			rip, rbp := getRipRbp(r.Debugger.Process.Pid)
-			r.lastRip = rip
-			r.lastRbp = rbp
-			funcName, _ := findFuncByRip(r.Debugger, rip)
-			r.lastFunc = funcName
-		}
-		return err
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go r.handleInterrupts(interrupts)
 
-	case "break", "b":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
-		if len(args) == 0 {
-			return fmt.Errorf("usage: break <address|function>")
-		}
+	return func() {
+		signal.Stop(interrupts)
+		runtime.UnlockOSThread()
+	}
+}
 
-		target := args[0]
-		var address uint64
+// executeLine 解析并执行一行命令
+func (r *REPL) executeLine(line string) error {
+	parts := strings.Fields(line)
+	if len(parts) == 0 {
+		return nil
+	}
 
-		// 解析为地址
-		if strings.HasPrefix(target, "0x") {
-			addr, err := strconv.ParseUint(target[2:], 16, 64)
-			if err != nil {
-				return fmt.Errorf("invalid address: %s", target)
-			}
-			address = addr
-		} else {
-			// 作为函数名查找
-			addr, err := r.Debugger.FindFunction(target)
-			if err != nil {
-				return err
-			}
-			address = addr
-		}
+	command := parts[0]
+	args := parts[1:]
+	// gdb 风格的 x/4xg：斜杠后的格式作为第一个参数
+	if i := strings.Index(command, "/"); i > 0 {
+		args = append([]string{command[i:]}, args...)
+		command = command[:i]
+	}
 
-		if err := r.Debugger.SetBreakpoint(address); err != nil {
-			return err
-		}
+	r.lastLine = ""
+	if r.repeatable(command) {
+		r.lastLine = line
+	}
 
-		fmt.Printf("Breakpoint set at 0x%x\n", address)
+	defer r.autoSaveBreakpoints()
+	if r.Format != OutputJSON {
+		return r.executeCommand(command, args)
+	}
+	rec := &Record{Command: line}
+	// 嵌套执行的命令记在外层命令的 Record 中，与外层一起输出
+	if n := len(r.records); n > 0 {
+		r.records[n-1].Commands = append(r.records[n-1].Commands, rec)
+	}
+	r.records = append(r.records, rec)
+	err := r.executeCommand(command, args)
+	r.records = r.records[:len(r.records)-1]
+	rec.OK = err == nil
+	if err != nil {
+		rec.Error = &RecordError{Code: errorCode(err), Message: err.Error()}
+	}
+	if len(r.records) == 0 {
+		r.writeRecord(rec)
+	}
+	return err
+}
 
-	case "delete", "d":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
-		if len(args) == 0 {
-			return fmt.Errorf("usage: delete <address>")
-		}
+// printError 文本模式下打印错误，JSON 模式下错误已经包含在命令的输出中
+func (r *REPL) printError(err error) {
+	if r.Format != OutputJSON {
+		fmt.Fprintf(r.out, "Error: %v\n", err)
+	}
+}
 
-		addr, err := strconv.ParseUint(args[0], 0, 64)
-		if err != nil {
-			return fmt.Errorf("invalid address: %s", args[0])
-		}
+// interactive 是否在终端前与用户交互，此时才输出多行输入的提示
+func (r *REPL) interactive() bool {
+	return r.sourceDepth == 0 && r.Format == OutputText
+}
 
-		if err := r.Debugger.RemoveBreakpoint(addr); err != nil {
-			return err
-		}
+// maxSourceDepth source 命令的最大嵌套层数
+const maxSourceDepth = 16
 
-		fmt.Printf("Breakpoint removed at 0x%x\n", addr)
+// Source 逐行执行脚本中的命令，空行和 # 开头的行被忽略，遇到第一个错误即停止
+func (r *REPL) Source(path string) error {
+	if r.sourceDepth >= maxSourceDepth {
+		return fmt.Errorf("source nested too deeply")
+	}
+	r.sourceDepth++
+	defer func() { r.sourceDepth-- }()
 
-	case "step", "s":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
-		err := r.Debugger.Step()
-		if err == nil && r.Debugger.IsRunning {
-			rip, rbp := getRipRbp(r.Debugger.Process.Pid)
-			r.lastRip = rip
-			r.lastRbp = rbp
-			funcName, _ := findFuncByRip(r.Debugger, rip)
-			r.lastFunc = funcName
-		}
+	f, err := os.Open(path)
+	if err != nil {
 		return err
-
-	case "registers", "regs", "r":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineno := 0
+	next := func(string) (string, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
 		}
+		lineno++
+		return scanner.Text(), nil
+	}
+	// commands ... end 等多行命令从脚本中继续读取
+	prev := r.input
+	r.input = next
+	defer func() { r.input = prev }()
 
-		registers, err := r.Debugger.GetRegisters()
+	for !r.quit {
+		input, err := next("")
 		if err != nil {
-			return err
-		}
-
-		fmt.Println("Registers:")
-		for name, value := range registers {
-			fmt.Printf("  %s: 0x%016x\n", name, value)
+			break
 		}
-
-	case "memory", "mem", "x":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
+		line := strings.TrimSpace(input)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-		if len(args) < 1 {
-			return fmt.Errorf("usage: memory <address> [size]")
+		start := lineno
+		if err := r.executeLine(line); err != nil {
+			return fmt.Errorf("%s:%d: %s: %w", path, start, line, err)
 		}
+	}
+	// 脚本中的命令不参与空行重复
+	r.lastLine = ""
+	return scanner.Err()
+}
 
-		addr, err := strconv.ParseUint(args[0], 0, 64)
-		if err != nil {
-			return fmt.Errorf("invalid address: %s", args[0])
-		}
+// initFiles 依次加载 $HOME/.tzdbinit 和当前项目目录下的 .tzdbinit
+func initFiles() []string {
+	var files []string
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		files = append(files, filepath.Join(home, ".tzdbinit"))
+	}
+	if cwd, err := os.Getwd(); err == nil && cwd != home {
+		files = append(files, filepath.Join(cwd, ".tzdbinit"))
+	}
+	return files
+}
 
-		size := 16 // 默认读取16字节
-		if len(args) > 1 {
-			if s, err := strconv.Atoi(args[1]); err == nil {
-				size = s
-			}
+// sourceInitFiles 执行存在的初始化文件，出错只打印不中断
+func (r *REPL) sourceInitFiles() {
+	for _, path := range initFiles() {
+		if _, err := os.Stat(path); err != nil {
+			continue
 		}
-
-		data, err := r.Debugger.ReadMemory(addr, size)
-		if err != nil {
-			return err
+		if err := r.Source(path); err != nil {
+			r.printError(err)
 		}
+	}
+}
 
-		r.printMemoryDump(addr, data)
-
-	case "stack", "bt":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
+// readLine 读取一行输入，输出不是终端时无法行编辑，改为直接逐行读取
+func (r *REPL) readLine(prompt string) (string, error) {
+	if r.fallback == nil {
+		input, err := r.line.Prompt(prompt)
+		if err != liner.ErrNotTerminalOutput {
+			return input, err
 		}
-
-		stackTrace, err := r.Debugger.GetStackTrace()
-		if err != nil {
-			return err
+		r.fallback = bufio.NewScanner(os.Stdin)
+	}
+	if r.Format != OutputJSON {
+		fmt.Fprint(r.out, prompt)
+	}
+	if !r.fallback.Scan() {
+		if err := r.fallback.Err(); err != nil {
+			return "", err
 		}
+		return "", io.EOF
+	}
+	return r.fallback.Text(), nil
+}
 
-		fmt.Println("Stack trace:")
-		for i, frame := range stackTrace {
-			fmt.Printf("  #%d: %s\n", i, frame)
+// findBreakpoint 按编号或 0x 开头的地址查找断点
+func (r *REPL) findBreakpoint(arg string) (*Breakpoint, error) {
+	if strings.HasPrefix(arg, "0x") {
+		addr, err := strconv.ParseUint(arg, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address: %s", arg)
 		}
-
-	case "breakpoints", "info":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
+		bp, ok := r.Debugger.Breakpoints[addr]
+		if !ok {
+			return nil, fmt.Errorf("no breakpoint at address 0x%x", addr)
 		}
+		return bp, nil
+	}
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid breakpoint: %s", arg)
+	}
+	bp := r.Debugger.BreakpointByID(id)
+	if bp == nil {
+		return nil, fmt.Errorf("no breakpoint number %d", id)
+	}
+	return bp, nil
+}
 
-		if len(r.Debugger.Breakpoints) == 0 {
-			fmt.Println("No breakpoints set")
-		} else {
-			fmt.Println("Breakpoints:")
-			for addr, bp := range r.Debugger.Breakpoints {
-				status := "enabled"
-				if !bp.Enabled {
-					status = "disabled"
-				}
-				fmt.Printf("  0x%x (%s)\n", addr, status)
+// handleInterrupts 程序运行时 Ctrl-C 暂停程序，否则只取消当前输入
+func (r *REPL) handleInterrupts(interrupts <-chan os.Signal) {
+	for range interrupts {
+		if d := r.running.Load(); d != nil {
+			if err := d.Interrupt(); err != nil {
+				fmt.Fprintf(r.out, "Error: %v\n", err)
 			}
+			continue
 		}
-
-	case "detach":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
-
-		if err := r.Debugger.Detach(); err != nil {
-			return err
+		if r.Format != OutputJSON {
+			fmt.Fprint(r.out, "\nQuit\n(tzdb) ")
 		}
+	}
+}
 
-		fmt.Println("Detached from process")
-		r.Debugger = nil
+// resume 运行 Continue/Step 等会让程序执行的操作，期间允许 Ctrl-C 中断。
+// 程序停下后更新当前帧信息，输出停止原因，并执行命中断点的命令列表。
+func (r *REPL) resume(run func() (StopReason, error)) error {
+	r.running.Store(r.Debugger)
+	reason, err := run()
+	r.running.Store(nil)
+	if err != nil {
+		return err
+	}
+	return r.reportStop(reason)
+}
 
-	case "kill":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
+// reportStop 报告程序停下的位置和原因，并执行命中的断点的命令列表
+func (r *REPL) reportStop(reason StopReason) error {
+	r.onStop()
+	res := r.stopResult()
+	res.Reason = &reason
+	r.output(res)
+	seq := r.Debugger.stopSeq
+	if err := r.runBreakpointCommands(); err != nil {
+		return err
+	}
+	// 断点命令让程序继续运行过时，JSON 记录中的结果换成最终停下的状态
+	if r.Format == OutputJSON && r.Debugger != nil && r.Debugger.stopSeq != seq {
+		res = r.stopResult()
+		res.Reason = r.Debugger.LastStop()
+		r.output(res)
+	}
+	return nil
+}
 
-		if err := r.Debugger.Kill(); err != nil {
-			return err
-		}
+// onStop 程序停下后回溯调用栈，并选中最内层的帧
+func (r *REPL) onStop() {
+	r.frames, r.frameIdx = nil, 0
+	d := r.Debugger
+	if d == nil || !d.IsRunning {
+		return
+	}
+	if frames, err := d.Stack(); err == nil && len(frames) > 0 {
+		r.frames = frames
+		return
+	}
+	// 没有栈回溯时只记录当前位置，局部变量不可用
+	if regs, err := d.registers(); err == nil {
+		r.frames = []Frame{d.frameAt(regs.PC())}
+	}
+}
 
-		fmt.Println("Process killed")
-		r.Debugger = nil
+// frame 返回选中的帧，程序未运行时返回 nil（此时只能访问全局变量）
+func (r *REPL) frame() *Frame {
+	if r.Debugger == nil || !r.Debugger.IsRunning || r.frameIdx >= len(r.frames) {
+		return nil
+	}
+	return &r.frames[r.frameIdx]
+}
 
-	case "quit", "q", "exit":
-		if r.Debugger != nil && r.Debugger.IsRunning {
-			r.Debugger.Detach()
-		}
-		fmt.Println("Goodbye!")
-		os.Exit(0)
-	case "print", "printvar":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
-		if len(args) == 0 {
-			return fmt.Errorf("usage: print <varname> [size]")
-		}
-		varname := args[0]
-		size := 8 // 默认读取8字节
-		if len(args) > 1 {
-			if s, err := strconv.Atoi(args[1]); err == nil {
-				size = s
-			}
-		}
-		var addr uint64
-		var err error
-		if r.lastFunc != "" && r.lastRbp != 0 {
-			addr, err = r.Debugger.FindVariableAddress(varname, r.lastRbp, r.lastFunc)
-		} else {
-			addr, err = r.Debugger.FindVariableAddress(varname, 0, "")
-		}
-		if err != nil {
-			return err
-		}
-		data, err := r.Debugger.ReadMemory(addr, size)
-		if err != nil {
-			return err
-		}
-		fmt.Printf("%s (0x%x): ", varname, addr)
-		for _, b := range data {
-			fmt.Printf("%02x ", b)
-		}
-		fmt.Println()
+// runBreakpointCommands 执行命中断点的命令列表。命令列表中的 continue/step 让程序
+// 再次运行后，剩余命令被跳过，改为执行新命中断点的命令列表（与 gdb 一致）。
+func (r *REPL) runBreakpointCommands() error {
+	if r.inCommands {
+		// 由外层的循环处理新的停止
 		return nil
+	}
+	r.inCommands = true
+	defer func() { r.inCommands = false }()
 
-	case "set", "setvar":
-		if r.Debugger == nil {
-			return fmt.Errorf("no program loaded")
-		}
-		if len(args) < 2 {
-			return fmt.Errorf("usage: set <varname> <value>")
-		}
-		varname := args[0]
-		valueStr := args[1]
-		var addr uint64
-		var err error
-		if r.lastFunc != "" && r.lastRbp != 0 {
-			addr, err = r.Debugger.FindVariableAddress(varname, r.lastRbp, r.lastFunc)
-		} else {
-			addr, err = r.Debugger.FindVariableAddress(varname, 0, "")
-		}
-		if err != nil {
-			return err
-		}
-		// 只支持写入8字节整数
-		value, err := strconv.ParseUint(valueStr, 0, 64)
-		if err != nil {
-			return fmt.Errorf("invalid value: %s", valueStr)
+	for r.Debugger != nil && r.Debugger.IsRunning {
+		bp := r.Debugger.HitBreakpoint()
+		if bp == nil || len(bp.Commands) == 0 {
+			return nil
 		}
-		data := make([]byte, 8)
-		for i := 0; i < 8; i++ {
-			data[i] = byte((value >> (8 * i)) & 0xFF)
+		seq := r.Debugger.stopSeq
+		for _, line := range bp.Commands {
+			if err := r.executeLine(line); err != nil {
+				return err
+			}
+			if r.Debugger == nil || r.Debugger.stopSeq != seq {
+				break
+			}
 		}
-		if err := r.Debugger.WriteMemory(addr, data); err != nil {
-			return err
+		if r.Debugger == nil || r.Debugger.stopSeq == seq {
+			return nil
 		}
-		fmt.Printf("Set %s (0x%x) = 0x%x\n", varname, addr, value)
-		return nil
-
-	default:
-		return fmt.Errorf("unknown command: %s", command)
 	}
 	return nil
 }
 
-// 获取 rip/rbp
-func getRipRbp(pid int) (uint64, uint64) {
-	var regs syscall.PtraceRegs
-	if err := syscall.PtraceGetRegs(pid, &regs); err != nil {
-		return 0, 0
-	}
-	return regs.Rip, regs.Rbp
-}
-
-// 通过 rip 查找当前函数名
-func findFuncByRip(dbg *Debugger, rip uint64) (string, error) {
-	if dbg.DwarfData == nil {
-		return "", fmt.Errorf("no DWARF data")
-	}
-	reader := dbg.DwarfData.Reader()
+// readBody 读取到 "end" 为止的命令块，用于 commands 等多行命令
+func (r *REPL) readBody() ([]string, error) {
+	var body []string
 	for {
-		entry, err := reader.Next()
-		if err != nil || entry == nil {
-			break
+		input, err := r.input(">")
+		if err != nil {
+			return nil, fmt.Errorf("unterminated command list (missing 'end')")
 		}
-		if entry.Tag == dwarf.TagSubprogram {
-			lowpc := entry.Val(dwarf.AttrLowpc)
-			highpc := entry.Val(dwarf.AttrHighpc)
-			nameAttr := entry.Val(dwarf.AttrName)
-			if lowpc != nil && highpc != nil && nameAttr != nil {
-				l := lowpc.(uint64)
-				h := highpc.(uint64)
-				if rip >= l && rip < h {
-					return nameAttr.(string), nil
-				}
-			}
+		line := strings.TrimSpace(input)
+		if line == "end" {
+			return body, nil
+		}
+		if line != "" && !strings.HasPrefix(line, "#") {
+			body = append(body, line)
 		}
 	}
-	return "", fmt.Errorf("func not found for rip")
-}
-
-// printHelp
-func (r *REPL) printHelp() {
-	fmt.Println(`Available commands:
-  help, h                    - Show this help message
-  launch, l <program> [args] - Launch a program for debugging
-  continue, c                - Continue execution
-  break, b <addr|func>       - Set a breakpoint
-  delete, d <addr>           - Remove a breakpoint
-  step, s                    - Execute one instruction
-  registers, regs, r         - Show register values
-  memory, mem, x <addr> [size] - Show memory contents
-  stack, bt                  - Show stack trace
-  breakpoints, info          - List all breakpoints
-  detach                     - Detach from process
-  kill                       - Kill the process
-  quit, q, exit              - Exit debugger
-
-Examples:
-  launch ./myprogram arg1 arg2
-  break main
-  break 0x401000
-  memory 0x7fff12345678 32
-  `)
 }
 
-// printMemoryDump
-func (r *REPL) printMemoryDump(startAddr uint64, data []byte) {
-	fmt.Printf("Memory dump at 0x%x:\n", startAddr)
+// printMemoryDump 以十六进制和 ASCII 两栏输出内存
+func printMemoryDump(w io.Writer, startAddr uint64, data []byte) {
+	fmt.Fprintf(w, "Memory dump at 0x%x:\n", startAddr)
 
 	for i := 0; i < len(data); i += 16 {
 		addr := startAddr + uint64(i)
-		fmt.Printf("%08x: ", addr)
+		fmt.Fprintf(w, "%08x: ", addr)
 
 		// 十六进制字节
 		for j := 0; j < 16 && i+j < len(data); j++ {
 			if j == 8 {
-				fmt.Print(" ")
+				fmt.Fprint(w, " ")
 			}
-			fmt.Printf("%02x ", data[i+j])
+			fmt.Fprintf(w, "%02x ", data[i+j])
 		}
 
 		// 填充空格
 		for j := len(data) - i; j < 16; j++ {
 			if j == 8 {
-				fmt.Print(" ")
+				fmt.Fprint(w, " ")
 			}
-			fmt.Print("   ")
+			fmt.Fprint(w, "   ")
 		}
 
 		// 打印 ASCII 表示
-		fmt.Print(" |")
+		fmt.Fprint(w, " |")
 		for j := 0; j < 16 && i+j < len(data); j++ {
 			b := data[i+j]
 			if b >= 32 && b <= 126 {
-				fmt.Printf("%c", b)
+				fmt.Fprintf(w, "%c", b)
 			} else {
-				fmt.Print(".")
+				fmt.Fprint(w, ".")
 			}
 		}
-		fmt.Println("|")
+		fmt.Fprintln(w, "|")
+	}
+}
+
+// historyFile 命令历史保存在 ~/.tzdb_history
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".tzdb_history")
+}
+
+func (r *REPL) loadHistory() {
+	path := historyFile()
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	r.line.ReadHistory(f)
+}
+
+func (r *REPL) saveHistory() {
+	path := historyFile()
+	if path == "" {
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return
 	}
+	defer f.Close()
+	r.line.WriteHistory(f)
 }
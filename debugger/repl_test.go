@@ -0,0 +1,127 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package debugger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// 每次运行都会变化的字段：进程和线程号、地址、栈上的位置
+var volatileKeys = map[string]bool{"pid": true, "thread": true, "child": true, "address": true, "pc": true, "cfa": true}
+
+// normalizeRecord 把一行 JSON 输出中随运行变化的值替换掉，源文件只保留文件名
+func normalizeRecord(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			switch {
+			case volatileKeys[k]:
+				v[k] = "*"
+			case k == "file":
+				if s, ok := val.(string); ok {
+					v[k] = filepath.Base(s)
+				}
+			default:
+				v[k] = normalizeRecord(val)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeRecord(v[i])
+		}
+	}
+	return v
+}
+
+// isProgramOutput 程序的输出在转发 goroutine 中异步到达，和命令的记录之间没有固定的顺序
+func isProgramOutput(rec map[string]interface{}) bool {
+	events, _ := rec["events"].([]interface{})
+	if rec["command"] != "" || len(events) == 0 {
+		return false
+	}
+	for _, e := range events {
+		if e.(map[string]interface{})["kind"] != string(EventOutput) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestScriptGolden 以 --output json 执行 testdata 中的脚本，与 .golden 文件比较
+func TestScriptGolden(t *testing.T) {
+	scripts, err := filepath.Glob("testdata/*.tzdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe := buildTestProgram(t)
+	t.Setenv("HOME", t.TempDir())
+
+	for _, script := range scripts {
+		name := strings.TrimSuffix(filepath.Base(script), ".tzdb")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), name+".tzdb")
+			if err := os.WriteFile(path, bytes.ReplaceAll(data, []byte("{{exe}}"), []byte(exe)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			// 每个脚本从没有自动保存的断点开始
+			if err := os.RemoveAll(filepath.Join(filepath.Dir(exe), ".tz-gin")); err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			r := NewREPL(nil)
+			r.Format = OutputJSON
+			r.out = &out
+			if err := r.RunScript(path); err != nil {
+				t.Fatalf("script failed: %v\n%s", err, out.String())
+			}
+
+			var got bytes.Buffer
+			// 命令行中的程序路径换回脚本中的写法
+			scanner := bufio.NewScanner(strings.NewReader(strings.ReplaceAll(out.String(), exe, "{{exe}}")))
+			scanner.Buffer(nil, 1<<20)
+			for scanner.Scan() {
+				var rec map[string]interface{}
+				if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+					t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+				}
+				if isProgramOutput(rec) {
+					continue
+				}
+				line, _ := json.Marshal(normalizeRecord(rec))
+				got.Write(line)
+				got.WriteByte('\n')
+			}
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", golden, got.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package debugger
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Rebuild 在 main 包的源码目录中重新编译程序并覆盖原来的可执行文件，
+// 沿用原来的 -gcflags、-ldflags、-tags 等构建参数。需要在进程结束后调用。
+func (d *Debugger) Rebuild() error {
+	dir, err := d.sourceDir()
+	if err != nil {
+		return err
+	}
+	output, err := filepath.Abs(d.Executable)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"build"}
+	env := os.Environ()
+	if info, err := buildinfo.ReadFile(d.Executable); err == nil {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "-gcflags", "-ldflags", "-asmflags", "-tags":
+				args = append(args, s.Key+"="+s.Value)
+			case "-trimpath", "-race", "-msan", "-asan":
+				if s.Value == "true" {
+					args = append(args, s.Key)
+				}
+			case "CGO_ENABLED", "GOOS", "GOARCH", "GOAMD64", "GOARM64":
+				env = append(env, s.Key+"="+s.Value)
+			}
+		}
+	}
+	args = append(args, "-o", output, ".")
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rebuild failed: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// rebreak 重新启动后一个断点的处理结果
+type rebreak struct {
+	ID      int    `json:"id"`
+	Spec    string `json:"spec"`
+	NewID   int    `json:"new_id,omitempty"`
+	Address uint64 `json:"address,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type restartResult struct {
+	Rebuilt     bool      `json:"rebuilt"`
+	PID         int       `json:"pid"`
+	Breakpoints []rebreak `json:"breakpoints"`
+}
+
+func (res restartResult) printText(w io.Writer) {
+	for _, b := range res.Breakpoints {
+		if b.Error != "" {
+			fmt.Fprintf(w, "Breakpoint %d (%s) not re-set: %s\n", b.ID, b.Spec, b.Error)
+		}
+	}
+}
+
+// cmdRestart restart [--rebuild] 重新启动程序；restart <id> 回到检查点
+func (r *REPL) cmdRestart(args []string) error {
+	if len(args) == 1 && !strings.HasPrefix(args[0], "-") {
+		return r.restartCheckpoint(args[0])
+	}
+	rebuild := false
+	for _, arg := range args {
+		if arg != "--rebuild" {
+			return fmt.Errorf("usage: restart [--rebuild] | restart <checkpoint id>")
+		}
+		rebuild = true
+	}
+	return r.relaunch(rebuild)
+}
+
+// relaunch 结束当前进程，以相同的参数重新启动（可选先重新编译），
+// 并按断点原来的位置描述重新解析地址，断点的命令列表随之保留
+func (r *REPL) relaunch(rebuild bool) error {
+	old := r.Debugger
+	if old.IsRunning {
+		if err := old.Kill(); err != nil {
+			return err
+		}
+	}
+	if rebuild {
+		if err := old.Rebuild(); err != nil {
+			return err
+		}
+	}
+
+	debugger, err := NewDebugger(old.Executable)
+	if err != nil {
+		return err
+	}
+	debugger.LaunchOptions = old.LaunchOptions
+	debugger.copyCatchpoints(old.Catchpoints)
+	r.setDebugger(debugger)
+	if err := debugger.Launch(old.ProgramArgs); err != nil {
+		return err
+	}
+
+	res := restartResult{Rebuilt: rebuild, PID: debugger.Process.Pid, Breakpoints: []rebreak{}}
+	for _, bp := range old.SortedBreakpoints() {
+		b := rebreak{ID: bp.ID, Spec: bp.Spec}
+		if b.Spec == "" {
+			b.Spec = fmt.Sprintf("0x%x", bp.Address)
+		}
+		var nbp *Breakpoint
+		var err error
+		if rebuild && strings.HasPrefix(b.Spec, "0x") {
+			err = fmt.Errorf("address breakpoints cannot be re-resolved after a rebuild")
+		} else {
+			nbp, err = r.setSavedBreakpoint(savedBreakpoint{Location: b.Spec, Condition: bp.Condition, Commands: bp.Commands})
+		}
+		if err != nil {
+			b.Error = err.Error()
+		} else {
+			b.NewID, b.Address = nbp.ID, nbp.Address
+		}
+		res.Breakpoints = append(res.Breakpoints, b)
+	}
+	r.onStop()
+	r.output(res)
+	return nil
+}
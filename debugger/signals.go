@@ -0,0 +1,144 @@
+package debugger
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SignalPolicy 被调试程序收到信号时的处理方式，与 gdb 的 handle 命令一致
+type SignalPolicy struct {
+	Stop  bool `json:"stop"`  // 暂停程序并回到提示符
+	Print bool `json:"print"` // 打印收到的信号
+	Pass  bool `json:"pass"`  // 恢复运行时把信号交给程序
+}
+
+// SignalTable 按信号编号保存处理策略，未配置的信号默认 stop print pass
+type SignalTable struct {
+	policies map[int]SignalPolicy
+}
+
+// signalInfo 平台已知的信号
+type signalInfo struct {
+	Name   string
+	Number int
+	Desc   string
+}
+
+func NewSignalTable() *SignalTable {
+	t := &SignalTable{policies: make(map[int]SignalPolicy)}
+	for sig, policy := range defaultSignalPolicies() {
+		t.policies[sig] = policy
+	}
+	return t
+}
+
+// Policy 返回信号的处理策略
+func (t *SignalTable) Policy(sig int) SignalPolicy {
+	if policy, ok := t.policies[sig]; ok {
+		return policy
+	}
+	return SignalPolicy{Stop: true, Print: true, Pass: true}
+}
+
+// Handle 解析并应用 "handle <signal> [stop|nostop|print|noprint|pass|nopass]..."，返回受影响的信号
+func (t *SignalTable) Handle(spec string, actions []string) ([]int, error) {
+	var sigs []int
+	if strings.EqualFold(spec, "all") {
+		for _, info := range knownSignals {
+			sigs = append(sigs, info.Number)
+		}
+	} else {
+		sig, err := parseSignal(spec)
+		if err != nil {
+			return nil, err
+		}
+		sigs = []int{sig}
+	}
+
+	for _, sig := range sigs {
+		policy := t.Policy(sig)
+		for _, action := range actions {
+			switch strings.ToLower(action) {
+			case "stop":
+				// 暂停必然打印
+				policy.Stop, policy.Print = true, true
+			case "nostop":
+				policy.Stop = false
+			case "print":
+				policy.Print = true
+			case "noprint":
+				// 不打印也就不暂停
+				policy.Print, policy.Stop = false, false
+			case "pass", "noignore":
+				policy.Pass = true
+			case "nopass", "ignore":
+				policy.Pass = false
+			default:
+				return nil, fmt.Errorf("unknown signal action: %s", action)
+			}
+		}
+		t.policies[sig] = policy
+	}
+	return sigs, nil
+}
+
+// Format 返回信号策略的表格行，sigs 为空时列出所有已知信号
+func (t *SignalTable) Format(sigs []int) []string {
+	if len(sigs) == 0 {
+		for _, info := range knownSignals {
+			sigs = append(sigs, info.Number)
+		}
+	}
+	sort.Ints(sigs)
+
+	yesNo := func(b bool) string {
+		if b {
+			return "Yes"
+		}
+		return "No"
+	}
+	lines := []string{fmt.Sprintf("%-12s %-6s %-6s %-6s %s", "Signal", "Stop", "Print", "Pass", "Description")}
+	for _, sig := range sigs {
+		policy := t.Policy(sig)
+		lines = append(lines, fmt.Sprintf("%-12s %-6s %-6s %-6s %s",
+			signalName(sig), yesNo(policy.Stop), yesNo(policy.Print), yesNo(policy.Pass), signalDesc(sig)))
+	}
+	return lines
+}
+
+// parseSignal 解析信号名（SIGINT、INT）或编号
+func parseSignal(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for _, info := range knownSignals {
+		if info.Name == name {
+			return info.Number, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal: %s", s)
+}
+
+func signalName(sig int) string {
+	for _, info := range knownSignals {
+		if info.Number == sig {
+			return info.Name
+		}
+	}
+	return fmt.Sprintf("SIG%d", sig)
+}
+
+func signalDesc(sig int) string {
+	for _, info := range knownSignals {
+		if info.Number == sig {
+			return info.Desc
+		}
+	}
+	return "unknown signal"
+}
@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package debugger
+
+import "syscall"
+
+var knownSignals = func() []signalInfo {
+	sigs := []struct {
+		name string
+		sig  syscall.Signal
+	}{
+		{"SIGHUP", syscall.SIGHUP}, {"SIGINT", syscall.SIGINT}, {"SIGQUIT", syscall.SIGQUIT},
+		{"SIGILL", syscall.SIGILL}, {"SIGTRAP", syscall.SIGTRAP}, {"SIGABRT", syscall.SIGABRT},
+		{"SIGBUS", syscall.SIGBUS}, {"SIGFPE", syscall.SIGFPE}, {"SIGKILL", syscall.SIGKILL},
+		{"SIGUSR1", syscall.SIGUSR1}, {"SIGSEGV", syscall.SIGSEGV}, {"SIGUSR2", syscall.SIGUSR2},
+		{"SIGPIPE", syscall.SIGPIPE}, {"SIGALRM", syscall.SIGALRM}, {"SIGTERM", syscall.SIGTERM},
+		{"SIGSTKFLT", syscall.SIGSTKFLT}, {"SIGCHLD", syscall.SIGCHLD}, {"SIGCONT", syscall.SIGCONT},
+		{"SIGSTOP", syscall.SIGSTOP}, {"SIGTSTP", syscall.SIGTSTP}, {"SIGTTIN", syscall.SIGTTIN},
+		{"SIGTTOU", syscall.SIGTTOU}, {"SIGURG", syscall.SIGURG}, {"SIGXCPU", syscall.SIGXCPU},
+		{"SIGXFSZ", syscall.SIGXFSZ}, {"SIGVTALRM", syscall.SIGVTALRM}, {"SIGPROF", syscall.SIGPROF},
+		{"SIGWINCH", syscall.SIGWINCH}, {"SIGIO", syscall.SIGIO}, {"SIGPWR", syscall.SIGPWR},
+		{"SIGSYS", syscall.SIGSYS},
+	}
+	infos := make([]signalInfo, len(sigs))
+	for i, s := range sigs {
+		infos[i] = signalInfo{Name: s.name, Number: int(s.sig), Desc: s.sig.String()}
+	}
+	return infos
+}()
+
+// defaultSignalPolicies 与 gdb 的默认值一致，另外 Go 运行时用 SIGURG 做异步抢占，默认静默转发
+func defaultSignalPolicies() map[int]SignalPolicy {
+	quiet := SignalPolicy{Stop: false, Print: false, Pass: true}
+	return map[int]SignalPolicy{
+		int(syscall.SIGURG):    quiet,
+		int(syscall.SIGCHLD):   quiet,
+		int(syscall.SIGWINCH):  quiet,
+		int(syscall.SIGPROF):   quiet,
+		int(syscall.SIGALRM):   quiet,
+		int(syscall.SIGVTALRM): quiet,
+		int(syscall.SIGIO):     quiet,
+		int(syscall.SIGPWR):    quiet,
+		int(syscall.SIGINT):    {Stop: true, Print: true, Pass: false},
+		int(syscall.SIGTRAP):   {Stop: true, Print: true, Pass: false},
+	}
+}
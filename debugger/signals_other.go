@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package debugger
+
+var knownSignals []signalInfo
+
+func defaultSignalPolicies() map[int]SignalPolicy {
+	return nil
+}
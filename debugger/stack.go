@@ -0,0 +1,134 @@
+package debugger
+
+import (
+	"fmt"
+)
+
+// maxStackDepth 回溯的最大帧数
+const maxStackDepth = 64
+
+// Frame 调用栈中的一帧
+type Frame struct {
+	PC   uint64 `json:"pc"`
+	CFA  uint64 `json:"cfa,omitempty"`
+	Func string `json:"func"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	regs  map[uint64]uint64 // 按 DWARF 编号恢复出的寄存器
+	outer bool              // 调用者帧，PC 是返回地址
+}
+
+func (f Frame) String() string {
+	name := f.Func
+	if name == "" {
+		name = "??"
+	}
+	if f.File == "" {
+		return fmt.Sprintf("0x%x %s", f.PC, name)
+	}
+	return fmt.Sprintf("0x%x %s at %s:%d", f.PC, name, f.File, f.Line)
+}
+
+// lookupPC 用于查找函数、行号和变量位置的地址。调用者帧的 PC 是返回地址，
+// 减一后才落在 call 指令所在的函数和行内。
+func (f *Frame) lookupPC() uint64 {
+	if f.outer {
+		return f.PC - 1
+	}
+	return f.PC
+}
+
+// stacktrace 根据 .debug_frame 中的 CFI 规则从当前线程回溯调用栈
+func (d *Debugger) stacktrace(max int) ([]Frame, error) {
+	if d.frames == nil || d.index == nil {
+		return nil, fmt.Errorf("no frame information loaded")
+	}
+	current, err := d.registers()
+	if err != nil {
+		return nil, err
+	}
+
+	regs := current.Dwarf()
+	pc := current.PC()
+	var frames []Frame
+	for len(frames) < max {
+		// 调用者帧的 pc 是返回地址，用 pc-1 定位到 call 指令所在的函数和行
+		probe := Frame{PC: pc, outer: len(frames) > 0}
+		lookupPC := probe.lookupPC()
+		frame := d.frameAt(lookupPC)
+		frame.PC, frame.regs, frame.outer = pc, regs, probe.outer
+		fn := d.index.funcByPC(lookupPC)
+
+		rules, err := d.frames.rulesAt(lookupPC)
+		if err != nil {
+			frames = append(frames, frame)
+			break
+		}
+		frame.CFA = regs[rules.cfaReg] + uint64(rules.cfaOffset)
+		frames = append(frames, frame)
+
+		if fn == nil || isOutermostFunc(fn.Name) {
+			break
+		}
+
+		caller, err := d.unwindRegisters(regs, rules, frame.CFA)
+		if err != nil {
+			break
+		}
+		ra, ok := caller[rules.raReg]
+		if !ok || ra == 0 {
+			break
+		}
+		pc, regs = ra, caller
+	}
+	return frames, nil
+}
+
+// frameAt 返回 pc 处的符号化信息（函数名、源文件和行号）
+func (d *Debugger) frameAt(pc uint64) Frame {
+	frame := Frame{PC: pc}
+	if d.index == nil {
+		return frame
+	}
+	if fn := d.index.funcByPC(pc); fn != nil {
+		frame.Func = fn.Name
+		frame.File, frame.Line, _ = d.index.pcToLine(pc)
+	}
+	return frame
+}
+
+// unwindRegisters 根据规则恢复调用者的寄存器
+func (d *Debugger) unwindRegisters(regs map[uint64]uint64, rules *frameRules, cfa uint64) (map[uint64]uint64, error) {
+	caller := make(map[uint64]uint64, len(regs))
+	for reg, val := range regs {
+		caller[reg] = val
+	}
+	for reg, rule := range rules.regs {
+		switch rule.kind {
+		case ruleUndefined:
+			delete(caller, reg)
+		case ruleOffset:
+			data, err := d.readMemory(cfa+uint64(rule.offset), d.frames.ptrSize)
+			if err != nil {
+				return nil, err
+			}
+			caller[reg] = readAddr(data, d.frames.order, d.frames.ptrSize)
+		case ruleValOffset:
+			caller[reg] = cfa + uint64(rule.offset)
+		case ruleRegister:
+			caller[reg] = regs[rule.reg]
+		}
+	}
+	caller[d.arch.DwarfSPReg()] = cfa
+	return caller, nil
+}
+
+// isOutermostFunc 栈底函数，回溯到这里为止
+func isOutermostFunc(name string) bool {
+	switch name {
+	case "runtime.goexit", "runtime.mstart", "runtime.rt0_go", "runtime.mcall", "runtime.morestack":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// LaunchOptions 被调试程序的标准输入输出、环境变量和工作目录，restart 时沿用
+type LaunchOptions struct {
+	Stdin  string   // 从文件读取标准输入
+	Stdout string   // 标准输出写入文件
+	Stderr string   // 标准错误写入文件，与 Stdout 相同时共用一个文件
+	TTY    string   // 没有重定向的标准输入输出连接到该终端，为空时自动分配一个伪终端
+	Env    []string // K=V，追加到调试器自己的环境变量之后，同名时覆盖
+	Dir    string   // 工作目录，为空时与调试器相同
+}
+
+// command 按 LaunchOptions 构造启动程序的命令，返回启动后调试器一侧需要关闭的文件。
+// 自动分配的伪终端保存在 d.pty 中，无法分配时（非 linux）继承调试器的标准输入输出。
+func (d *Debugger) command(args []string) (*exec.Cmd, []*os.File, error) {
+	// 符号是从调试器工作目录下的这个文件加载的，启动的也必须是它，而不是按程序的工作目录或 PATH 查找
+	path, err := filepath.Abs(d.Executable)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := d.LaunchOptions
+	cmd := exec.Command(path, args...)
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+
+	var files []*os.File
+	open := func(name string, flag int) (*os.File, error) {
+		f, err := os.OpenFile(name, flag, 0644)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, err
+		}
+		files = append(files, f)
+		return f, nil
+	}
+	if opts.Stdin != "" {
+		f, err := open(opts.Stdin, os.O_RDONLY)
+		if err != nil {
+			return nil, nil, err
+		}
+		cmd.Stdin = f
+	}
+	if opts.Stdout != "" {
+		f, err := open(opts.Stdout, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return nil, nil, err
+		}
+		cmd.Stdout = f
+	}
+	if opts.Stderr != "" && opts.Stderr == opts.Stdout {
+		cmd.Stderr = cmd.Stdout
+	} else if opts.Stderr != "" {
+		f, err := open(opts.Stderr, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return nil, nil, err
+		}
+		cmd.Stderr = f
+	}
+	if cmd.Stdin != nil && cmd.Stdout != nil && cmd.Stderr != nil {
+		return cmd, files, nil
+	}
+
+	var term *os.File
+	if opts.TTY != "" {
+		f, err := open(opts.TTY, os.O_RDWR|syscall.O_NOCTTY)
+		if err != nil {
+			return nil, nil, err
+		}
+		term = f
+	} else if master, slave, err := openPty(); err == nil {
+		d.pty = master
+		files = append(files, slave)
+		term = slave
+	}
+	if cmd.Stdin == nil {
+		cmd.Stdin = fileOr(term, os.Stdin)
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = fileOr(term, os.Stdout)
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = fileOr(term, os.Stderr)
+	}
+	return cmd, files, nil
+}
+
+func fileOr(f, def *os.File) *os.File {
+	if f != nil {
+		return f
+	}
+	return def
+}
+
+// startCommand 启动命令并关闭调试器一侧不再需要的文件，使用伪终端时开始转发程序的输出
+func (d *Debugger) startCommand(cmd *exec.Cmd, files []*os.File) error {
+	err := cmd.Start()
+	for _, f := range files {
+		f.Close()
+	}
+	if err != nil {
+		if d.pty != nil {
+			d.pty.Close()
+			d.pty = nil
+		}
+		return fmt.Errorf("failed to start process: %v", err)
+	}
+	if d.pty != nil {
+		d.outputDone = make(chan struct{})
+		go d.forwardOutput(d.pty, d.outputDone)
+	}
+	return nil
+}
+
+// forwardOutput 在单独的 goroutine 中读取伪终端，直到所有打开终端的进程都退出
+func (d *Debugger) forwardOutput(master *os.File, done chan struct{}) {
+	defer close(done)
+	defer master.Close()
+	buf := make([]byte, 4096)
+	for {
+		n, err := master.Read(buf)
+		if n > 0 {
+			data := append([]byte(nil), buf[:n]...)
+			if d.OnOutput != nil {
+				d.OnOutput(data)
+			} else {
+				os.Stdout.Write(data)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// flushOutput 进程退出时等待剩余的输出转发完，让输出出现在退出事件之前。
+// 检查点进程也打开着终端，因此最多等待一小段时间。
+func (d *Debugger) flushOutput() {
+	if d.outputDone == nil {
+		return
+	}
+	select {
+	case <-d.outputDone:
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// WriteInput 向自动分配的伪终端写入内容，作为程序的标准输入
+func (d *Debugger) WriteInput(data string) error {
+	if !d.IsRunning {
+		return errNotRunning
+	}
+	if d.pty == nil || d.LaunchOptions.Stdin != "" {
+		return fmt.Errorf("the program's stdin is not a terminal allocated by the debugger (see launch --stdin/--tty)")
+	}
+	if _, err := d.pty.WriteString(data); err != nil {
+		return fmt.Errorf("failed to write to the program's terminal: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package debugger
+
+// Next 执行到当前函数的下一行源码，函数调用整体跳过。
+// 途中命中用户断点、收到需要暂停的信号或进程退出时提前停下。
+func (d *Debugger) Next() (StopReason, error) {
+	if !d.IsRunning {
+		return StopReason{}, errNotRunning
+	}
+	if !d.Capabilities().Step || !d.Capabilities().StackTrace {
+		return StopReason{}, unsupported("next")
+	}
+	return d.run(d.next)
+}
+
+func (d *Debugger) next() error {
+	frames, err := d.stacktrace(1)
+	if err != nil {
+		return err
+	}
+	start := frames[0]
+	if start.File == "" {
+		// 没有行号信息，退化为单条指令
+		return d.singleStep()
+	}
+
+	for {
+		if err := d.singleStep(); err != nil {
+			return err
+		}
+		if !d.IsRunning {
+			return nil
+		}
+		frames, err := d.stacktrace(2)
+		if err != nil {
+			return err
+		}
+		cur := frames[0]
+		switch {
+		case cur.CFA < start.CFA && len(frames) > 1:
+			// 进入了被调用函数，在返回地址处停下后继续单步
+			stopped, err := d.runToReturn(frames[1].PC, start.CFA)
+			if err != nil || stopped {
+				return err
+			}
+			continue
+		case cur.CFA > start.CFA:
+			// 当前函数已返回
+			return nil
+		}
+		if cur.File != start.File || cur.Line != start.Line {
+			return nil
+		}
+	}
+}
+
+// runToReturn 在返回地址 ret 处设置内部断点并继续运行，直到回到 CFA 为 cfa 的帧。
+// 返回 true 表示途中因为其他原因（用户断点、信号、进程退出）停下。
+func (d *Debugger) runToReturn(ret, cfa uint64) (bool, error) {
+	tid := d.tid
+	if _, exists := d.Breakpoints[ret]; !exists {
+		orig, err := d.insertBreakpoint(ret)
+		if err != nil {
+			return false, err
+		}
+		d.Breakpoints[ret] = &Breakpoint{Address: ret, Original: orig, Enabled: true, internal: true}
+		defer func() {
+			if d.IsRunning {
+				d.clearBreakpoint(d.Breakpoints[ret])
+			}
+			delete(d.Breakpoints, ret)
+		}()
+	}
+
+	for {
+		if err := d.cont(); err != nil {
+			return false, err
+		}
+		if !d.IsRunning || d.hit != nil {
+			return true, nil
+		}
+		frames, err := d.stacktrace(1)
+		if err != nil {
+			return false, err
+		}
+		if frames[0].PC != ret {
+			return true, nil
+		}
+		// 其他线程执行到同一地址，或递归调用中更深的一层返回时继续运行
+		if d.tid == tid && frames[0].CFA >= cfa {
+			return false, nil
+		}
+	}
+}
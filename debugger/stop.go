@@ -0,0 +1,138 @@
+package debugger
+
+import (
+	"fmt"
+)
+
+// StopKind 程序停下的原因
+type StopKind string
+
+const (
+	StopBreakpoint   StopKind = "breakpoint"
+	StopSignal       StopKind = "signal"
+	StopExited       StopKind = "exited"
+	StopKilled       StopKind = "killed"     // 被信号杀死
+	StopWatchpoint   StopKind = "watchpoint" // 预留给观察点，目前不会产生
+	StopCatchpoint   StopKind = "catchpoint"
+	StopFork         StopKind = "fork" // vfork 的子进程保持暂停，父进程无法继续运行
+	StopInterrupted  StopKind = "interrupted"
+	StopStep         StopKind = "step" // step、next 正常完成
+	StopEndOfHistory StopKind = "end_of_history"
+)
+
+// StopReason Continue、Step 等让程序运行的操作结束时程序停下的原因
+type StopReason struct {
+	Kind       StopKind `json:"kind"`
+	Thread     int      `json:"thread,omitempty"`
+	Breakpoint int      `json:"breakpoint,omitempty"`
+	Catchpoint int      `json:"catchpoint,omitempty"`
+	Address    uint64   `json:"address,omitempty"`
+	Signal     string   `json:"signal,omitempty"`
+	ExitCode   *int     `json:"exit_code,omitempty"`
+	Frame      *Frame   `json:"frame,omitempty"`
+}
+
+func (s StopReason) String() string {
+	var str string
+	switch s.Kind {
+	case StopBreakpoint:
+		str = fmt.Sprintf("Stopped at breakpoint %d", s.Breakpoint)
+	case StopWatchpoint:
+		str = fmt.Sprintf("Stopped at watchpoint %d", s.Breakpoint)
+	case StopCatchpoint:
+		str = fmt.Sprintf("Stopped at catchpoint %d", s.Catchpoint)
+	case StopSignal:
+		sig, _ := parseSignal(s.Signal)
+		str = fmt.Sprintf("Stopped by signal %s, %s", s.Signal, signalDesc(sig))
+	case StopExited:
+		str = "Process exited"
+		if s.ExitCode != nil {
+			str = fmt.Sprintf("Process exited with code %d", *s.ExitCode)
+		}
+	case StopKilled:
+		str = "Process killed"
+		if s.Signal != "" {
+			sig, _ := parseSignal(s.Signal)
+			str = fmt.Sprintf("Process killed by signal %s, %s", s.Signal, signalDesc(sig))
+		}
+	case StopInterrupted:
+		str = "Interrupted"
+	case StopFork:
+		str = "Stopped at vfork"
+	case StopEndOfHistory:
+		str = "Reached the start of the recorded history"
+	default:
+		str = "Stopped"
+	}
+	if s.Thread != 0 && s.Frame != nil {
+		str += fmt.Sprintf(" in thread %d", s.Thread)
+	}
+	if s.Frame != nil {
+		str += ": " + s.Frame.String()
+	}
+	return str
+}
+
+// stopReasonOf 表示停止的事件对应的停止原因，其他事件返回 nil
+func stopReasonOf(e Event) *StopReason {
+	s := &StopReason{Thread: e.Thread, Frame: e.Frame}
+	switch e.Kind {
+	case EventBreakpointHit:
+		s.Kind, s.Breakpoint, s.Address = StopBreakpoint, e.Breakpoint, e.Address
+	case EventSignal, EventStopped:
+		s.Kind, s.Signal = StopSignal, e.Signal
+	case EventInterrupted:
+		s.Kind = StopInterrupted
+	case EventFork:
+		s.Kind = StopFork
+	case EventSyscallEntry, EventSyscallReturn, EventPanic:
+		s.Kind, s.Catchpoint = StopCatchpoint, e.Catchpoint
+		if s.Frame == nil && len(e.Stack) > 0 {
+			s.Frame = &e.Stack[0]
+		}
+	case EventExited:
+		s.Kind, s.ExitCode = StopExited, e.ExitCode
+	case EventKilled:
+		s.Kind, s.Signal = StopKilled, e.Signal
+	case EventHistoryStart:
+		s.Kind = StopEndOfHistory
+	default:
+		return nil
+	}
+	return s
+}
+
+// emitStop 发出表示程序停下的事件，并记录为这次运行的停止原因
+func (d *Debugger) emitStop(e Event) {
+	if s := stopReasonOf(e); s != nil {
+		if s.Frame == nil && s.Thread != 0 && d.IsRunning {
+			s.Frame = d.locationOf(s.Thread)
+		}
+		d.stop = s
+	}
+	d.emit(e)
+}
+
+// run 执行让程序运行的操作，返回程序停下的原因。
+// 没有停止事件时（step、next 正常完成）原因为 StopStep，位置为当前线程的位置。
+func (d *Debugger) run(op func() error) (StopReason, error) {
+	d.hit = nil
+	d.stopSeq++
+	d.stop = nil
+	if err := op(); err != nil {
+		return StopReason{}, err
+	}
+	if d.stop == nil {
+		s := StopReason{Kind: StopStep}
+		if d.IsRunning {
+			s.Thread, s.Frame = d.tid, d.locationOf(d.tid)
+		}
+		d.stop = &s
+	}
+	return *d.stop, nil
+}
+
+// LastStop 返回最近一次 Continue、Step 等操作结束时的停止原因，程序还没有运行过时返回 nil
+func (d *Debugger) LastStop() *StopReason {
+	return d.stop
+}
@@ -0,0 +1,7 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package debugger
+
+// syscallNames 没有系统调用捕获点支持的平台上为空
+var syscallNames = map[int]string{}
@@ -0,0 +1,409 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// tuiOutputLines 底部输出区域的行数
+const tuiOutputLines = 8
+
+// tui 全屏界面：左侧源码（带断点标记和当前行），右侧调用栈和局部变量，
+// 底部为命令输出和命令行。命令行交给 REPL 执行，界面在每条命令后刷新。
+type tui struct {
+	r      *REPL
+	screen tcell.Screen
+	events chan tcell.Event
+
+	prompt  string
+	input   []rune
+	history []string
+	histPos int
+	output  []string
+	partial string     // 命令输出中还没有换行的部分
+	mu      sync.Mutex // 保护 output 和 partial，程序的输出在另一个 goroutine 中到达
+
+	// 程序运行时输出 goroutine 也会重绘界面，drawMu 保护绘制和绘制用到的状态
+	drawMu sync.Mutex
+
+	// 最近一次停止时的状态
+	frames   []Frame
+	selected int
+	locals   []string
+	bpLines  map[string]map[int]bool // 文件 -> 设置了断点的行
+	sources  map[string][]string
+}
+
+// StartTUI 以全屏界面运行 REPL，F5/F10/F11 分别为 continue/next/step
+func (r *REPL) StartTUI() error {
+	defer r.attach()()
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return fmt.Errorf("failed to open terminal: %v", err)
+	}
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("failed to initialize terminal: %v", err)
+	}
+	defer screen.Fini()
+
+	t := &tui{
+		r:       r,
+		screen:  screen,
+		events:  make(chan tcell.Event, 16),
+		sources: make(map[string][]string),
+	}
+	out, printOutput := r.out, r.printOutput
+	defer func() { r.out, r.printOutput = out, printOutput }()
+	r.input = t.readLine
+	r.out = t
+	r.printOutput = t.printOutput
+	go t.poll()
+
+	t.print("TZGin2 Debugger v1.0")
+	t.print("F5 continue  F10 next  F11 step  Ctrl-C interrupt  Ctrl-D quit")
+	r.sourceInitFiles()
+	t.flush()
+	t.refresh()
+
+	for !r.quit {
+		line, err := t.readLine("(tzdb) ")
+		if err != nil {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if r.lastLine == "" {
+				continue
+			}
+			line = r.lastLine
+		}
+		t.print("(tzdb) " + line)
+		if err := r.executeLine(line); err != nil {
+			r.printError(err)
+		}
+		t.flush()
+		t.refresh()
+	}
+	return nil
+}
+
+// poll 在单独的 goroutine 中读取终端事件。程序运行时主线程阻塞在 Continue 中，
+// Ctrl-C 直接在这里中断程序，程序的新输出也在这里重绘，其余事件排队等待主线程处理。
+func (t *tui) poll() {
+	for {
+		ev := t.screen.PollEvent()
+		if ev == nil {
+			close(t.events)
+			return
+		}
+		if d := t.r.running.Load(); d != nil {
+			switch ev := ev.(type) {
+			case *tcell.EventKey:
+				if ev.Key() == tcell.KeyCtrlC {
+					d.Interrupt()
+					continue
+				}
+			case *tcell.EventInterrupt:
+				t.draw()
+				continue
+			}
+		}
+		t.events <- ev
+	}
+}
+
+// readLine 在底部命令行读取一行输入，也用于 commands/define 等多行命令
+func (t *tui) readLine(prompt string) (string, error) {
+	t.drawMu.Lock()
+	t.prompt, t.input, t.histPos = prompt, nil, len(t.history)
+	t.drawMu.Unlock()
+	t.draw()
+	for ev := range t.events {
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			t.screen.Sync()
+		case *tcell.EventKey:
+			t.drawMu.Lock()
+			line, done, err := t.handleKey(ev)
+			t.drawMu.Unlock()
+			if done {
+				return line, err
+			}
+		}
+		// 其他事件（如程序输出发来的 EventInterrupt）只需要重绘
+		t.draw()
+	}
+	return "", io.EOF
+}
+
+// handleKey 处理一次按键，done 为 true 时结束当前输入
+func (t *tui) handleKey(ev *tcell.EventKey) (line string, done bool, err error) {
+	// 快捷键只在主提示符下生效，多行输入时不触发
+	shortcuts := map[tcell.Key]string{
+		tcell.KeyF5:  "continue",
+		tcell.KeyF10: "next",
+		tcell.KeyF11: "step",
+	}
+	if cmd, ok := shortcuts[ev.Key()]; ok {
+		if t.prompt != "(tzdb) " {
+			return "", false, nil
+		}
+		return cmd, true, nil
+	}
+
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		line = string(t.input)
+		if strings.TrimSpace(line) != "" {
+			t.history = append(t.history, line)
+		}
+		return line, true, nil
+	case tcell.KeyCtrlD:
+		if len(t.input) == 0 {
+			return "", true, io.EOF
+		}
+	case tcell.KeyCtrlC:
+		t.input = nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(t.input) > 0 {
+			t.input = t.input[:len(t.input)-1]
+		}
+	case tcell.KeyUp:
+		if t.histPos > 0 {
+			t.histPos--
+			t.input = []rune(t.history[t.histPos])
+		}
+	case tcell.KeyDown:
+		if t.histPos < len(t.history)-1 {
+			t.histPos++
+			t.input = []rune(t.history[t.histPos])
+		} else {
+			t.histPos, t.input = len(t.history), nil
+		}
+	case tcell.KeyRune:
+		t.input = append(t.input, ev.Rune())
+	}
+	return "", false, nil
+}
+
+// Write 作为 REPL 的输出：命令结果和事件按行追加到输出区域，没有换行的部分留到下一次写入
+func (t *tui) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(t.partial+string(p), "\n")
+	for _, line := range lines[:len(lines)-1] {
+		t.appendLine(line)
+	}
+	t.partial = lines[len(lines)-1]
+	return len(p), nil
+}
+
+// flush 命令执行完后把没有换行的输出也放进输出区域
+func (t *tui) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.partial != "" {
+		t.appendLine(t.partial)
+		t.partial = ""
+	}
+}
+
+// print 追加一行到输出区域
+func (t *tui) print(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.appendLine(line)
+}
+
+// printOutput 在转发 goroutine 中接收程序的一行输出，并通知界面重绘
+func (t *tui) printOutput(line string) {
+	t.print(line)
+	t.screen.PostEvent(tcell.NewEventInterrupt(nil))
+}
+
+// appendLine 只保留最近的输出，调用者持有 mu
+func (t *tui) appendLine(line string) {
+	t.output = append(t.output, line)
+	if len(t.output) > 1000 {
+		t.output = t.output[len(t.output)-1000:]
+	}
+}
+
+// refresh 程序停下后读取调用栈，以及选中帧的局部变量
+func (t *tui) refresh() {
+	var frames []Frame
+	var selected int
+	var locals []string
+	if frame := t.r.frame(); frame != nil {
+		frames, selected = t.r.frames, t.r.frameIdx
+		if vars, err := t.r.Debugger.frameVariables(frame); err == nil {
+			for _, v := range vars {
+				locals = append(locals, fmt.Sprintf("%s = %s", v.Name, v.Value))
+			}
+		}
+	}
+	// 断点的位置也在这里算好，程序运行时重绘不访问调试器的状态
+	bpLines := make(map[string]map[int]bool)
+	if d := t.r.Debugger; d != nil {
+		for _, bp := range d.SortedBreakpoints() {
+			loc := d.frameAt(bp.Address)
+			if bpLines[loc.File] == nil {
+				bpLines[loc.File] = make(map[int]bool)
+			}
+			bpLines[loc.File][loc.Line] = true
+		}
+	}
+	t.drawMu.Lock()
+	t.frames, t.selected, t.locals, t.bpLines = frames, selected, locals, bpLines
+	t.drawMu.Unlock()
+}
+
+// sourceLines 读取并缓存源文件
+func (t *tui) sourceLines(file string) []string {
+	if lines, ok := t.sources[file]; ok {
+		return lines
+	}
+	var lines []string
+	if data, err := os.ReadFile(file); err == nil {
+		lines = strings.Split(strings.ReplaceAll(string(data), "\t", "    "), "\n")
+	}
+	t.sources[file] = lines
+	return lines
+}
+
+// draw 重绘整个界面
+func (t *tui) draw() {
+	t.drawMu.Lock()
+	defer t.drawMu.Unlock()
+	s := t.screen
+	s.Clear()
+	w, h := s.Size()
+	outTop := h - 1 - tuiOutputLines - 1
+	if outTop < 4 {
+		outTop = h / 2
+	}
+	srcW := w * 2 / 3
+	title := tcell.StyleDefault.Reverse(true)
+
+	// 源码
+	var cur *Frame
+	if t.selected < len(t.frames) {
+		cur = &t.frames[t.selected]
+	}
+	name := "Source"
+	if cur != nil && cur.File != "" {
+		name = "Source: " + cur.File
+	}
+	t.text(0, 0, srcW, title, padRight(" "+name, srcW))
+	t.drawSource(cur, 0, 1, srcW, outTop-1)
+
+	// 调用栈和局部变量
+	for y := 0; y < outTop; y++ {
+		s.SetContent(srcW, y, '│', nil, tcell.StyleDefault)
+	}
+	rightX, rightW := srcW+1, w-srcW-1
+	stackH := (outTop - 2) / 2
+	t.text(rightX, 0, rightW, title, padRight(" Stack", rightW))
+	for i, frame := range t.frames {
+		if i >= stackH {
+			break
+		}
+		line := fmt.Sprintf("#%d %s", i, frame.Func)
+		if frame.File != "" {
+			line += fmt.Sprintf(" :%d", frame.Line)
+		}
+		style := tcell.StyleDefault
+		if i == t.selected {
+			style = style.Bold(true)
+		}
+		t.text(rightX, 1+i, rightW, style, line)
+	}
+	localsTop := 1 + stackH
+	t.text(rightX, localsTop, rightW, title, padRight(" Locals", rightW))
+	for i, local := range t.locals {
+		if localsTop+1+i >= outTop {
+			break
+		}
+		t.text(rightX, localsTop+1+i, rightW, tcell.StyleDefault, local)
+	}
+
+	// 输出和命令行
+	t.text(0, outTop, w, title, padRight(" Output", w))
+	t.mu.Lock()
+	out := t.output
+	t.mu.Unlock()
+	if n := h - 1 - (outTop + 1); len(out) > n && n >= 0 {
+		out = out[len(out)-n:]
+	}
+	for i, line := range out {
+		t.text(0, outTop+1+i, w, tcell.StyleDefault, line)
+	}
+	prompt := t.prompt + string(t.input)
+	t.text(0, h-1, w, tcell.StyleDefault, prompt)
+	s.ShowCursor(runewidth.StringWidth(prompt), h-1)
+	s.Show()
+}
+
+// drawSource 以当前行为中心绘制源码，左侧标记断点（●）和当前行（=>）
+func (t *tui) drawSource(cur *Frame, x, y, w, h int) {
+	if cur == nil || cur.File == "" {
+		t.text(x, y, w, tcell.StyleDefault, "No source for the current location")
+		return
+	}
+	lines := t.sourceLines(cur.File)
+	if lines == nil {
+		t.text(x, y, w, tcell.StyleDefault, "Cannot read "+cur.File)
+		return
+	}
+
+	breakpoints := t.bpLines[cur.File]
+	first := cur.Line - h/2
+	if first < 1 {
+		first = 1
+	}
+	for i := 0; i < h; i++ {
+		n := first + i
+		if n > len(lines) {
+			break
+		}
+		gutter := "  "
+		if breakpoints[n] {
+			gutter = "● "
+		}
+		style := tcell.StyleDefault
+		if n == cur.Line {
+			gutter = "=>"
+			style = style.Reverse(true)
+		}
+		gutterStyle := tcell.StyleDefault.Foreground(tcell.ColorRed)
+		t.text(x, y+i, 2, gutterStyle, gutter)
+		t.text(x+2, y+i, w-2, style, padRight(fmt.Sprintf("%4d  %s", n, lines[n-1]), w-2))
+	}
+}
+
+// text 在 (x, y) 处绘制一行文本，超出宽度的部分截断
+func (t *tui) text(x, y, w int, style tcell.Style, s string) {
+	col := 0
+	for _, r := range s {
+		rw := runewidth.RuneWidth(r)
+		if col+rw > w {
+			break
+		}
+		t.screen.SetContent(x+col, y, r, nil, style)
+		col += rw
+	}
+}
+
+func padRight(s string, w int) string {
+	if n := w - runewidth.StringWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
@@ -0,0 +1,347 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxValueDepth 嵌套结构体、数组最多展开的层数
+	maxValueDepth = 3
+	// maxValueElems 数组和切片最多显示的元素个数
+	maxValueElems = 16
+)
+
+// Variable 按 DWARF 类型解码后的变量
+type Variable struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Address uint64 `json:"address"`
+	Value   string `json:"value"`
+}
+
+// ReadVariable 在帧 frame 中查找变量（frame 为 nil 时只查全局变量），按其 DWARF 类型读取和格式化值
+func (d *Debugger) ReadVariable(name string, frame *Frame) (Variable, error) {
+	return d.ReadVariableFormat(name, frame, 0)
+}
+
+// ReadVariableFormat 与 ReadVariable 相同，但整数、字符、布尔值和指针（包括结构体字段和数组元素）
+// 按 x 命令的格式字母 radix（x d u o t c）输出，radix 为 0 时使用默认格式
+func (d *Debugger) ReadVariableFormat(name string, frame *Frame, radix byte) (Variable, error) {
+	entry, loc, err := d.findVariable(name, frame)
+	if err != nil {
+		return Variable{}, err
+	}
+	typ, err := d.entryType(entry)
+	if err != nil {
+		return Variable{}, fmt.Errorf("variable '%s': %v", name, err)
+	}
+	return d.formatVariable(name, typ, loc, radix), nil
+}
+
+// ReadVariableBytes 读取变量开头的 size 字节原始内容，寄存器中的变量读取 DWARF 组合出的值
+func (d *Debugger) ReadVariableBytes(name string, frame *Frame, size int) (Variable, []byte, error) {
+	entry, loc, err := d.findVariable(name, frame)
+	if err != nil {
+		return Variable{}, nil, err
+	}
+	v := Variable{Name: name, Address: loc.addr}
+	if typ, err := d.entryType(entry); err == nil {
+		v.Type = typ.String()
+	}
+	if loc.data != nil {
+		if size > len(loc.data) {
+			size = len(loc.data)
+		}
+		return v, loc.data[:size], nil
+	}
+	data, err := d.ReadMemory(loc.addr, size)
+	return v, data, err
+}
+
+// SetVariable 按变量的类型解析 value 并写回变量，寄存器中的变量写入当前线程的寄存器。
+// 支持整数、布尔值、浮点数和指针，返回写入后的值
+func (d *Debugger) SetVariable(name string, frame *Frame, value string) (Variable, error) {
+	if !d.IsRunning {
+		return Variable{}, errNotRunning
+	}
+	entry, loc, err := d.findVariable(name, frame)
+	if err != nil {
+		return Variable{}, err
+	}
+	typ, err := d.entryType(entry)
+	if err != nil {
+		return Variable{}, fmt.Errorf("variable '%s': %v", name, err)
+	}
+	data, err := parseValue(typ, value)
+	if err != nil {
+		return Variable{}, err
+	}
+	if err := d.writeLocation(loc, frame, data); err != nil {
+		return Variable{}, fmt.Errorf("variable '%s': %v", name, err)
+	}
+	if loc.data != nil {
+		copy(loc.data, data)
+	}
+	return d.newVariable(name, typ, loc), nil
+}
+
+// parseValue 按类型 t 把文本解析为内存中的表示
+func parseValue(t dwarf.Type, s string) ([]byte, error) {
+	size := underlying(t).Size()
+	if size <= 0 || size > 8 {
+		return nil, fmt.Errorf("cannot set a value of type %s", t)
+	}
+	bits := int(size * 8)
+	var v uint64
+	var err error
+	switch underlying(t).(type) {
+	case *dwarf.IntType, *dwarf.CharType:
+		var i int64
+		i, err = strconv.ParseInt(s, 0, bits)
+		v = uint64(i)
+	case *dwarf.UintType, *dwarf.UcharType:
+		v, err = strconv.ParseUint(s, 0, bits)
+	case *dwarf.PtrType, *dwarf.AddrType:
+		if s != "nil" {
+			v, err = strconv.ParseUint(s, 0, bits)
+		}
+	case *dwarf.BoolType:
+		var b bool
+		if b, err = strconv.ParseBool(s); b {
+			v = 1
+		}
+	case *dwarf.FloatType:
+		var f float64
+		f, err = strconv.ParseFloat(s, bits)
+		if v = math.Float64bits(f); bits == 32 {
+			v = uint64(math.Float32bits(float32(f)))
+		}
+	default:
+		return nil, fmt.Errorf("cannot set a value of type %s", t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value: %s", t, s)
+	}
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, v)
+	return data[:size], nil
+}
+
+// newVariable 读取并格式化位置 loc 处的值，寄存器中的变量没有地址
+func (d *Debugger) newVariable(name string, typ dwarf.Type, loc location) Variable {
+	return d.formatVariable(name, typ, loc, 0)
+}
+
+// formatVariable 读取位置 loc 处的值，按 radix 格式化
+func (d *Debugger) formatVariable(name string, typ dwarf.Type, loc location, radix byte) Variable {
+	v := Variable{Name: name, Type: typ.String(), Address: loc.addr}
+	if loc.data != nil {
+		v.Value = valueReader{d: d, data: loc.data, radix: radix}.format(typ, registerValueBase, 0)
+	} else {
+		v.Value = valueReader{d: d, radix: radix}.format(typ, loc.addr, 0)
+	}
+	return v
+}
+
+// entryType 读取 DIE 的 DW_AT_type
+func (d *Debugger) entryType(entry *dwarf.Entry) (dwarf.Type, error) {
+	off, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+	if !ok {
+		return nil, fmt.Errorf("no type information")
+	}
+	return d.DwarfData.Type(off)
+}
+
+// valueReader 按类型读取值。寄存器中的变量被映射到 registerValueBase 开始的伪地址，
+// 值内部的指针（如字符串数据）仍然读取进程内存。radix 不为 0 时标量按 x 命令的格式字母输出。
+type valueReader struct {
+	d     *Debugger
+	data  []byte
+	radix byte
+}
+
+// read 读取 n 字节，伪地址范围内的读取来自 data
+func (v valueReader) read(addr uint64, n int) ([]byte, error) {
+	if v.data != nil && addr >= registerValueBase {
+		off := addr - registerValueBase
+		if off+uint64(n) > uint64(len(v.data)) {
+			return nil, fmt.Errorf("value is only partially available")
+		}
+		return v.data[off : off+uint64(n)], nil
+	}
+	return v.d.ReadMemory(addr, n)
+}
+
+// words 读取 n 个 8 字节的小端序整数
+func (v valueReader) words(addr uint64, n int) ([]uint64, error) {
+	data, err := v.read(addr, n*8)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]uint64, n)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+	return words, nil
+}
+
+// format 读取 addr 处类型为 t 的值并格式化
+func (v valueReader) format(t dwarf.Type, addr uint64, depth int) string {
+	u := underlying(t)
+	size := u.Size()
+	if size <= 0 {
+		if _, ok := u.(*dwarf.StructType); ok {
+			return "{}"
+		}
+		return "<unavailable>"
+	}
+	var data []byte
+	switch u.(type) {
+	case *dwarf.StructType, *dwarf.ArrayType:
+		// 按字段或元素分别读取，避免一次读取很大的对象
+	default:
+		var err error
+		if data, err = v.read(addr, int(size)); err != nil {
+			return fmt.Sprintf("<unreadable at 0x%x>", addr)
+		}
+	}
+
+	if v.radix != 0 {
+		switch u.(type) {
+		case *dwarf.IntType, *dwarf.CharType, *dwarf.UintType, *dwarf.UcharType, *dwarf.BoolType, *dwarf.PtrType, *dwarf.AddrType:
+			return formatRadix(data, v.radix)
+		}
+	}
+
+	switch u := u.(type) {
+	case *dwarf.IntType, *dwarf.CharType:
+		return strconv.FormatInt(signExtend(data), 10)
+	case *dwarf.UintType, *dwarf.UcharType:
+		return strconv.FormatUint(littleEndian(data), 10)
+	case *dwarf.BoolType:
+		return strconv.FormatBool(data[0] != 0)
+	case *dwarf.FloatType:
+		if size == 4 {
+			return strconv.FormatFloat(float64(math.Float32frombits(uint32(littleEndian(data)))), 'g', -1, 32)
+		}
+		return strconv.FormatFloat(math.Float64frombits(littleEndian(data)), 'g', -1, 64)
+	case *dwarf.ComplexType:
+		half := data[:size/2]
+		if size == 8 {
+			return fmt.Sprintf("(%g+%gi)", math.Float32frombits(uint32(littleEndian(half))), math.Float32frombits(uint32(littleEndian(data[4:]))))
+		}
+		return fmt.Sprintf("(%g+%gi)", math.Float64frombits(littleEndian(half)), math.Float64frombits(littleEndian(data[8:])))
+	case *dwarf.PtrType, *dwarf.AddrType:
+		if p := littleEndian(data); p != 0 {
+			return fmt.Sprintf("0x%x", p)
+		}
+		return "nil"
+	case *dwarf.StructType:
+		return v.formatStruct(u, addr, depth)
+	case *dwarf.ArrayType:
+		if depth >= maxValueDepth {
+			return "[...]"
+		}
+		return v.formatElems(u.Type, addr, u.Count, depth)
+	}
+	return fmt.Sprintf("<%s at 0x%x>", t, addr)
+}
+
+// formatStruct 格式化字符串、切片、接口和普通结构体
+func (v valueReader) formatStruct(t *dwarf.StructType, addr uint64, depth int) string {
+	switch {
+	case t.StructName == "string":
+		w, err := v.words(addr, 2)
+		if err != nil {
+			return fmt.Sprintf("<unreadable at 0x%x>", addr)
+		}
+		return v.d.readGoString(w[0], w[1])
+	case strings.HasPrefix(t.StructName, "[]") && len(t.Field) == 3:
+		w, err := v.words(addr, 3)
+		if err != nil {
+			return fmt.Sprintf("<unreadable at 0x%x>", addr)
+		}
+		ptr, ok := underlying(t.Field[0].Type).(*dwarf.PtrType)
+		if w[0] == 0 || !ok || depth >= maxValueDepth {
+			return fmt.Sprintf("%s len: %d, cap: %d", t.StructName, w[1], w[2])
+		}
+		return fmt.Sprintf("%s len: %d, cap: %d, %s", t.StructName, w[1], w[2], v.formatElems(ptr.Type, w[0], int64(w[1]), depth))
+	case t.StructName == "runtime.eface":
+		w, err := v.words(addr, 2)
+		if err != nil {
+			return fmt.Sprintf("<unreadable at 0x%x>", addr)
+		}
+		return v.d.formatInterface(w[0], w[1])
+	case t.StructName == "runtime.iface":
+		w, err := v.words(addr, 2)
+		if err != nil {
+			return fmt.Sprintf("<unreadable at 0x%x>", addr)
+		}
+		var typ uint64
+		if w[0] != 0 {
+			// itab 的第二个字段是动态类型
+			if tw, err := v.d.readWords(w[0]+8, 1); err == nil {
+				typ = tw[0]
+			}
+		}
+		return v.d.formatInterface(typ, w[1])
+	}
+
+	if depth >= maxValueDepth {
+		return t.StructName + "{...}"
+	}
+	fields := make([]string, 0, len(t.Field))
+	for _, f := range t.Field {
+		fields = append(fields, f.Name+": "+v.format(f.Type, addr+uint64(f.ByteOffset), depth+1))
+	}
+	return t.StructName + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// formatElems 格式化从 addr 开始的 n 个元素，超过 maxValueElems 的部分省略
+func (v valueReader) formatElems(elem dwarf.Type, addr uint64, n int64, depth int) string {
+	size := elem.Size()
+	shown := n
+	if shown > maxValueElems {
+		shown = maxValueElems
+	}
+	elems := make([]string, 0, shown+1)
+	for i := int64(0); i < shown; i++ {
+		elems = append(elems, v.format(elem, addr+uint64(i*size), depth+1))
+	}
+	if shown < n {
+		elems = append(elems, fmt.Sprintf("...+%d more", n-shown))
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// formatRadix 按 print/<fmt> 的方式格式化小端序的标量，与 x 命令不同，x 和 t 不补齐前导零
+func formatRadix(b []byte, radix byte) string {
+	switch radix {
+	case 'x':
+		return fmt.Sprintf("0x%x", littleEndian(b))
+	case 't':
+		return strconv.FormatUint(littleEndian(b), 2)
+	}
+	return formatUnit(b, examineFormat{format: radix})
+}
+
+// littleEndian 把最多 8 字节的小端序数据解释为无符号整数
+func littleEndian(b []byte) uint64 {
+	var buf [8]byte
+	copy(buf[:], b)
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// signExtend 把最多 8 字节的小端序数据解释为有符号整数
+func signExtend(b []byte) int64 {
+	if len(b) == 0 || len(b) > 8 {
+		return 0
+	}
+	bits := uint(64 - len(b)*8)
+	return int64(littleEndian(b)<<bits) >> bits
+}
@@ -0,0 +1,143 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+)
+
+// Locals 返回帧 frame 中 pc 处可见的局部变量（不含参数）及其值
+func (d *Debugger) Locals(frame *Frame) ([]Variable, error) {
+	return d.frameVariables(frame, dwarf.TagVariable)
+}
+
+// Args 返回帧 frame 所在函数的参数（包括返回值）及其值
+func (d *Debugger) Args(frame *Frame) ([]Variable, error) {
+	return d.frameVariables(frame, dwarf.TagFormalParameter)
+}
+
+// frameVariables 读取帧 frame 中可见的变量，tags 为空时返回参数和局部变量。
+// 无法定位的变量仍然列出，值为错误原因。
+func (d *Debugger) frameVariables(frame *Frame, tags ...dwarf.Tag) ([]Variable, error) {
+	if d.index == nil {
+		return nil, fmt.Errorf("no DWARF data loaded")
+	}
+	if frame == nil {
+		return nil, errNotRunning
+	}
+	fn := d.index.funcByPC(frame.lookupPC())
+	if fn == nil {
+		return nil, fmt.Errorf("no function at 0x%x", frame.PC)
+	}
+	vars := []Variable{}
+	for _, entry := range d.index.scopeVariables(fn, frame.lookupPC()) {
+		if len(tags) > 0 && !hasTag(tags, entry.Tag) {
+			continue
+		}
+		name := entry.Val(dwarf.AttrName).(string)
+		vars = append(vars, d.entryVariable(name, entry, fn, frame))
+	}
+	return vars, nil
+}
+
+// PackageVars 返回名字匹配 re 的包级变量及其值，按名字排序
+func (d *Debugger) PackageVars(re *regexp.Regexp) ([]Variable, error) {
+	if d.index == nil {
+		return nil, fmt.Errorf("no DWARF data loaded")
+	}
+	var names []string
+	for name := range d.index.globals {
+		if re == nil || re.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	vars := []Variable{}
+	for _, name := range names {
+		entry, err := d.index.lookupGlobal(name)
+		if err != nil {
+			continue
+		}
+		vars = append(vars, d.entryVariable(name, entry, nil, nil))
+	}
+	return vars, nil
+}
+
+// entryVariable 定位并读取变量 DIE，出错时把原因放在值里
+func (d *Debugger) entryVariable(name string, entry *dwarf.Entry, fn *funcEntry, frame *Frame) Variable {
+	typ, err := d.entryType(entry)
+	if err != nil {
+		return Variable{Name: name, Value: fmt.Sprintf("<%v>", err)}
+	}
+	loc, err := d.variableLocation(entry, fn, frame)
+	if err != nil {
+		return Variable{Name: name, Type: typ.String(), Value: fmt.Sprintf("<%v>", err)}
+	}
+	return d.newVariable(name, typ, loc)
+}
+
+func hasTag(tags []dwarf.Tag, tag dwarf.Tag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// variablesResult locals、args 和 vars 的输出，empty 是没有变量时的提示
+type variablesResult struct {
+	Variables []Variable `json:"variables"`
+
+	empty string
+}
+
+func (res variablesResult) printText(w io.Writer) {
+	if len(res.Variables) == 0 {
+		fmt.Fprintln(w, res.empty)
+		return
+	}
+	for _, v := range res.Variables {
+		fmt.Fprintf(w, "%s = %s\n", v.Name, v.Value)
+	}
+}
+
+// cmdLocals locals 列出选中帧中可见的局部变量
+func (r *REPL) cmdLocals(args []string) error {
+	vars, err := r.Debugger.Locals(r.frame())
+	if err != nil {
+		return err
+	}
+	r.output(variablesResult{Variables: vars, empty: "No locals."})
+	return nil
+}
+
+// cmdArgs args 列出选中帧所在函数的参数
+func (r *REPL) cmdArgs(args []string) error {
+	vars, err := r.Debugger.Args(r.frame())
+	if err != nil {
+		return err
+	}
+	r.output(variablesResult{Variables: vars, empty: "No arguments."})
+	return nil
+}
+
+// cmdVars vars [regex] 列出名字匹配正则表达式的包级变量
+func (r *REPL) cmdVars(args []string) error {
+	var re *regexp.Regexp
+	if len(args) > 0 {
+		var err error
+		if re, err = regexp.Compile(args[0]); err != nil {
+			return fmt.Errorf("invalid regular expression: %v", err)
+		}
+	}
+	vars, err := r.Debugger.PackageVars(re)
+	if err != nil {
+		return err
+	}
+	r.output(variablesResult{Variables: vars, empty: "No matching variables."})
+	return nil
+}
@@ -26,6 +26,8 @@ type WindowsDebugger struct {
 const (
 	DEBUG_PROCESS             = 0x00000001
 	DEBUG_ONLY_THIS_PROCESS   = 0x00000002
+	EXCEPTION_DEBUG_EVENT     = 1
+	EXIT_PROCESS_DEBUG_EVENT  = 5
 	CREATE_SUSPENDED          = 0x00000004
 	EXCEPTION_BREAKPOINT      = 0x80000003
 	EXCEPTION_SINGLE_STEP     = 0x80000004
@@ -140,47 +142,71 @@ func (d *WindowsDebugger) Launch(args []string) error {
 	return nil
 }
 
-// Continue 继续执行
-func (d *WindowsDebugger) Continue() error {
+// Continue 继续执行，直到遇到断点、单步、未处理的异常或进程退出
+func (d *WindowsDebugger) Continue() (StopReason, error) {
 	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
-	}
-
-	var debugEvent DEBUG_EVENT
-
-	// 等待调试事件
-	ret, _, err := procWaitForDebugEvent.Call(
-		uintptr(unsafe.Pointer(&debugEvent)),
-		syscall.INFINITE,
-	)
-
-	if ret == 0 {
-		return fmt.Errorf("WaitForDebugEvent failed: %v", err)
+		return StopReason{}, fmt.Errorf("process is not running")
 	}
 
-	continueStatus := uint32(DBG_CONTINUE)
-
-	// 处理调试事件
-	switch debugEvent.DebugEventCode {
-	case EXCEPTION_BREAKPOINT:
-		fmt.Printf("Breakpoint hit at 0x%x\n", debugEvent.Exception.ExceptionRecord.ExceptionAddress)
-		// 这里可以添加断点处理逻辑
-
-	case EXCEPTION_SINGLE_STEP:
-		fmt.Println("Single step completed")
-
-	default:
-		continueStatus = DBG_EXCEPTION_NOT_HANDLED
+	for {
+		var debugEvent DEBUG_EVENT
+
+		// 等待调试事件
+		ret, _, err := procWaitForDebugEvent.Call(
+			uintptr(unsafe.Pointer(&debugEvent)),
+			syscall.INFINITE,
+		)
+
+		if ret == 0 {
+			return StopReason{}, fmt.Errorf("WaitForDebugEvent failed: %v", err)
+		}
+
+		continueStatus := uint32(DBG_CONTINUE)
+		var reason *StopReason
+		record := debugEvent.Exception.ExceptionRecord
+
+		// 处理调试事件
+		switch debugEvent.DebugEventCode {
+		case EXCEPTION_DEBUG_EVENT:
+			addr := uint64(record.ExceptionAddress)
+			switch record.ExceptionCode {
+			case EXCEPTION_BREAKPOINT:
+				// 调试器不插入断点，这里只会是加载器的初始断点或程序自身的 int3
+				reason = &StopReason{Kind: StopBreakpoint, Thread: int(debugEvent.ThreadId), Address: addr}
+			case EXCEPTION_SINGLE_STEP:
+				reason = &StopReason{Kind: StopStep, Thread: int(debugEvent.ThreadId), Address: addr}
+			default:
+				// 第一次机会的异常交给程序自己处理
+				continueStatus = DBG_EXCEPTION_NOT_HANDLED
+				if debugEvent.Exception.FirstChance == 0 {
+					reason = &StopReason{Kind: StopSignal, Thread: int(debugEvent.ThreadId), Address: addr,
+						Signal: fmt.Sprintf("exception 0x%x", record.ExceptionCode)}
+				}
+			}
+
+		case EXIT_PROCESS_DEBUG_EVENT:
+			// EXIT_PROCESS_DEBUG_INFO 的 dwExitCode 与 ExceptionCode 位于联合体的同一位置
+			code := int(record.ExceptionCode)
+			reason = &StopReason{Kind: StopExited, ExitCode: &code}
+			d.IsRunning = false
+		}
+
+		// 继续执行
+		procContinueDebugEvent.Call(
+			uintptr(debugEvent.ProcessId),
+			uintptr(debugEvent.ThreadId),
+			uintptr(continueStatus),
+		)
+
+		if reason != nil {
+			return *reason, nil
+		}
 	}
+}
 
-	// 继续执行
-	procContinueDebugEvent.Call(
-		uintptr(debugEvent.ProcessId),
-		uintptr(debugEvent.ThreadId),
-		uintptr(continueStatus),
-	)
-
-	return nil
+// Step 单步执行，尚未实现
+func (d *WindowsDebugger) Step() (StopReason, error) {
+	return StopReason{}, unsupported("step")
 }
 
 // ReadMemory 读取内存
@@ -230,32 +256,10 @@ func (d *WindowsDebugger) WriteMemory(address uint64, data []byte) error {
 	return nil
 }
 
-// SetBreakpoint 设置断点
+// SetBreakpoint 设置断点，尚未实现。命中 int3 后需要恢复原字节、通过线程上下文回退 RIP、
+// 单步后重新插入，而线程上下文的读写还没有实现，插入的 int3 会让程序在指令中间继续执行
 func (d *WindowsDebugger) SetBreakpoint(address uint64) error {
-	if !d.IsRunning {
-		return fmt.Errorf("process is not running")
-	}
-
-	// 读取原始字节
-	originalByte, err := d.ReadMemory(address, 1)
-	if err != nil {
-		return fmt.Errorf("failed to read original byte: %v", err)
-	}
-
-	// 写入断点指令 (int3 = 0xCC)
-	breakpointByte := []byte{0xCC}
-	if err := d.WriteMemory(address, breakpointByte); err != nil {
-		return fmt.Errorf("failed to write breakpoint: %v", err)
-	}
-
-	// 保存断点信息
-	d.Breakpoints[address] = &Breakpoint{
-		Address:      address,
-		OriginalByte: originalByte[0],
-		Enabled:      true,
-	}
-
-	return nil
+	return unsupported("breakpoints")
 }
 
 // RemoveBreakpoint 移除断点
@@ -266,8 +270,7 @@ func (d *WindowsDebugger) RemoveBreakpoint(address uint64) error {
 	}
 
 	// 恢复原始字节
-	originalByte := []byte{breakpoint.OriginalByte}
-	if err := d.WriteMemory(address, originalByte); err != nil {
+	if err := d.WriteMemory(address, breakpoint.Original); err != nil {
 		return fmt.Errorf("failed to restore original byte: %v", err)
 	}
 
@@ -275,11 +278,20 @@ func (d *WindowsDebugger) RemoveBreakpoint(address uint64) error {
 	return nil
 }
 
-// GetRegisters 获取寄存器 (简化实现)
+// GetRegisters 获取寄存器，尚未实现线程上下文读取
 func (d *WindowsDebugger) GetRegisters() (map[string]uint64, error) {
-	// 这里需要实现获取线程上下文的逻辑
-	// 由于复杂性，这里返回一个空的映射
-	return make(map[string]uint64), nil
+	return nil, unsupported("registers")
+}
+
+// Capabilities Windows 后端支持的操作
+func (d *WindowsDebugger) Capabilities() Capabilities {
+	return Capabilities{
+		Launch:      true,
+		Continue:    true,
+		ReadMemory:  true,
+		WriteMemory: true,
+		Kill:        true,
+	}
 }
 
 // Kill 终止进程
@@ -16,8 +16,24 @@ var banner string
 
 func main() {
 	app := app.InitApp(configStirng)
-	util.SuccessMsg(banner)
+	if !jsonOutput(os.Args[1:]) {
+		util.SuccessMsg(banner)
+	}
 
 	app.Run(os.Args)
+}
 
+// jsonOutput 判断是否使用了 --output json，此时标准输出只能包含 JSON，不打印 banner
+func jsonOutput(args []string) bool {
+	for i, arg := range args {
+		switch arg {
+		case "--output=json", "-output=json":
+			return true
+		case "--output", "-output":
+			if i+1 < len(args) && args[i+1] == "json" {
+				return true
+			}
+		}
+	}
+	return false
 }